@@ -12,11 +12,11 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"golang.org/x/oauth2"
 
 	"gioui.org/app"
-	"gioui.org/gesture"
 	"gioui.org/io/key"
 	"gioui.org/io/system"
 	"gioui.org/layout"
@@ -25,6 +25,8 @@ import (
 
 	"github.com/google/go-github/v24/github"
 
+	"github.com/dejadejade/giox/fn"
+
 	_ "image/jpeg"
 	_ "image/png"
 
@@ -46,6 +48,7 @@ var (
 	prof  = flag.Bool("profile", false, "serve profiling data at http://localhost:6060")
 	stats = flag.Bool("stats", false, "show rendering statistics")
 	token = flag.String("token", "", "Github authentication token")
+	sheet = flag.String("styles", "", "load a .giox stylesheet and reload it when it changes")
 )
 
 func main() {
@@ -78,12 +81,19 @@ func initProfiling() {
 
 func (a *App) run() error {
 	a.ui.profiling = *stats
+	if *sheet != "" {
+		s, err := fn.LoadStylesheet(*sheet)
+		if err != nil {
+			return err
+		}
+		s.Watch(time.Second, a.w.Invalidate)
+		defer s.Close()
+	}
 	var ops op.Ops
 	for {
 		select {
 		case users := <-a.updateUsers:
 			a.ui.users = users
-			a.ui.userClicks = make([]gesture.Click, len(users))
 			a.w.Invalidate()
 		case commits := <-a.commitsResult:
 			a.ui.selectedUser.commits = commits
@@ -99,6 +109,11 @@ func (a *App) run() error {
 						a.ui.profiling = !a.ui.profiling
 						a.w.Invalidate()
 					}
+				case "D":
+					if e.Modifiers.Contain(key.ModShortcut) {
+						fn.SetDebug(!fn.Debugging())
+						a.w.Invalidate()
+					}
 				}
 			case system.DestroyEvent:
 				return e.Err
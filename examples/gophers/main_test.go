@@ -22,3 +22,17 @@ func BenchmarkUI(b *testing.B) {
 		u.Layout(gtx)
 	}
 }
+
+func BenchmarkCommit(b *testing.B) {
+	u := &user{avatar: image.NewRGBA(image.Rect(0, 0, 48, 48))}
+	var ops op.Ops
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ops.Reset()
+		gtx := layout.Context{
+			Ops:         &ops,
+			Constraints: layout.Exact(image.Pt(800, 600)),
+		}
+		Commit(gtx, u, "fn: compile and cache style strings")
+	}
+}
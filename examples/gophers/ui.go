@@ -12,9 +12,7 @@ import (
 	"runtime"
 
 	"gioui.org/font/gofont"
-	"gioui.org/gesture"
 	"gioui.org/io/key"
-	"gioui.org/io/pointer"
 	"gioui.org/io/profile"
 	"gioui.org/layout"
 	"gioui.org/op/paint"
@@ -36,9 +34,7 @@ type UI struct {
 	fab          *widget.Clickable
 	fabIcon      *widget.Icon
 	fabIcon2     *widget.Icon
-	usersList    *layout.List
 	users        []*user
-	userClicks   []gesture.Click
 	selectedUser *userPage
 	edit, edit2  *widget.Editor
 	fetchCommits func(u string)
@@ -50,9 +46,8 @@ type UI struct {
 }
 
 type userPage struct {
-	user        *user
-	commitsList *layout.List
-	commits     []*github.Commit
+	user    *user
+	commits []*github.Commit
 }
 
 type user struct {
@@ -75,15 +70,13 @@ func init() {
 	theme = material.NewTheme(gofont.Collection())
 	theme.Color.Text = rgb(0x333333)
 	theme.Color.Hint = rgb(0xbbbbbb)
+	fn.SetTheme(fn.MaterialTheme(theme))
 }
 
 func newUI(fetchCommits func(string)) *UI {
 	u := &UI{
 		fetchCommits: fetchCommits,
 	}
-	u.usersList = &layout.List{
-		Axis: layout.Vertical,
-	}
 	u.fab = new(widget.Clickable)
 	u.edit2 = &widget.Editor{
 		//Alignment: text.End,
@@ -136,19 +129,17 @@ func (u *UI) layoutTimings(gtx layout.Context) string {
 
 func (u *UI) newUserPage(user *user) *userPage {
 	up := &userPage{
-		user:        user,
-		commitsList: &layout.List{Axis: layout.Vertical},
+		user: user,
 	}
 	u.fetchCommits(user.login)
 	return up
 }
 
 func (u *UI) Layout(gtx layout.Context) {
-	for i := range u.userClicks {
-		click := &u.userClicks[i]
-		for _, e := range click.Events(gtx) {
-			if e.Type == gesture.TypeClick {
-				u.selectedUser = u.newUserPage(u.users[i])
+	for _, user := range u.users {
+		for _, e := range fn.Clicked(gtx, user) {
+			if e.Kind == fn.Click {
+				u.selectedUser = u.newUserPage(user)
 			}
 		}
 	}
@@ -156,6 +147,7 @@ func (u *UI) Layout(gtx layout.Context) {
 	if u.fab.Clicked() {
 		if u.selectedUser != nil {
 			u.selectedUser = nil
+			fn.Forget("commits")
 		}
 	}
 
@@ -167,21 +159,22 @@ func (u *UI) Layout(gtx layout.Context) {
 
 	if u.profiling {
 		txt := u.layoutTimings(gtx)
-		fn.Styled(material.Caption(theme, txt).Layout, fn.Direction(layout.NE), fn.Margin4(0, 16, 0, 0))(gtx)
+		fn.Styled(fn.Text("font(caption)", txt), fn.Direction(layout.NE), fn.Margin4(0, 16, 0, 0))(gtx)
 	}
 }
 
 func UserPage(gtx C, u *UI) D {
 	up := u.selectedUser
-	content := func(gtx C) D {
-		list := up.commitsList
-		if list.Dragging() {
-			key.HideInputOp{}.Add(gtx.Ops)
-		}
-		return list.Layout(gtx, len(up.commits), func(gtx C, i int) D {
-			return Commit(gtx, up.user, up.commits[i].GetMessage())
-		})
+	if fn.ListDragging("commits") {
+		key.HideInputOp{}.Add(gtx.Ops)
 	}
+	content := fn.FormatF("list(v,key(commits),scrollbar)",
+		fn.Items(len(up.commits), func(i int) fn.ChildSpec {
+			return fn.Child("", func(gtx C) D {
+				return Commit(gtx, up.user, up.commits[i].GetMessage())
+			})
+		}),
+	)
 
 	return fn.Format(gtx, "stack(se)",
 		fn.Child("e(0)", content),
@@ -190,40 +183,43 @@ func UserPage(gtx C, u *UI) D {
 }
 
 func Commit(gtx C, user *user, msg string) D {
-	return fn.Format(gtx, "hflex;border(0,0,0,1,a0b0c0);inset(8,16,8,8)",
+	return fn.Format(gtx, "hflex;border(0,0,0,1px,a0b0c0);inset(8,16,8,8)",
 		fn.Child(";rounded(48)", Avatar(user)),
-		fn.Child("f;inset(8,0,0,0)", material.Caption(theme, msg).Layout))
+		fn.Child("f;inset(8,0,0,0)", fn.Text("font(caption);maxlines(3);ellipsis", msg)))
 }
 
-func User(gtx C, user *user, click *gesture.Click) D {
-	dims := fn.Format(gtx, "hflex(middle);inset(8)",
+func User(gtx C, user *user) D {
+	row := fn.FormatF("hflex(middle);inset(8)",
 		fn.Child(";inset(8);rounded(36)", Avatar(user)),
-		fn.Child(";border(0,0,0,1,e0e0e0);inset(0,0,0,16)", fn.FormatF("vflex",
+		fn.Child(";border(0,0,0,1px,e0e0e0);inset(0,0,0,16)", fn.FormatF("vflex",
 			fn.Child("", fn.FormatF("hflex(baseline)",
-				fn.Child("", material.Body1(theme, user.name).Layout),
-				fn.Child("f(1);dir(e);inset(2,0,0,0)", material.Caption(theme, "3 hours ago").Layout)),
+				fn.Child("", fn.Text("", user.name)),
+				fn.Child("f(1);dir(e);inset(2,0,0,0)", fn.Text("font(caption)", "3 hours ago"))),
 			),
-			fn.Child(";inset(0,4,0,0)", material.Caption(theme, user.company).Layout),
+			fn.Child(";inset(0,4,0,0)", fn.Text("font(caption)", user.company)),
 		)),
 	)
-
-	pointer.Rect(image.Rectangle{Max: dims.Size}).Add(gtx.Ops)
-	click.Add(gtx.Ops)
-	return dims
+	// Rows are keyed by user, so each row hovers on its own.
+	return fn.Styled(row,
+		fn.Clickable(user),
+		fn.Interactive(user, fn.Hovered|fn.Pressed),
+		fn.When(fn.Hovered, fn.Background(rgb(0xf5f5f5))),
+		fn.When(fn.Pressed, fn.Background(rgb(0xe8e8e8))),
+	)(gtx)
 }
 
 func Users(gtx C, u *UI) D {
 	content := fn.FormatF("vflex",
-		fn.Child("r(1);inset(16);size(400,200)", material.Editor(theme, u.edit, "Hint").Layout),
+		fn.Child("r(1);inset(16);size(400,200);scroll(key(edit),scrollbar)", material.Editor(theme, u.edit, "Hint").Layout),
 		fn.Child("r(1);inset(16)", material.Editor(theme, u.edit2, "Hint").Layout),
-		fn.Child("r;bkground(f2f2f2);inset(8)", material.Caption(theme, "GOPHERS").Layout),
-		fn.Child("f(1)", func(gtx C) D {
-			return u.usersList.Layout(gtx, len(u.users), func(gtx C, index int) D {
-				user := u.users[index]
-				click := &u.userClicks[index]
-				return User(gtx, user, click)
-			})
-		}),
+		fn.Child("r;bkground(f2f2f2);inset(8)", fn.Text("font(caption)", "GOPHERS")),
+		fn.Child("f(1)", fn.FormatF("list(v,key(users))",
+			fn.Items(len(u.users), func(i int) fn.ChildSpec {
+				return fn.Child("", func(gtx C) D {
+					return User(gtx, u.users[i])
+				})
+			}),
+		)),
 	)
 
 	return fn.Format(gtx, "stack(se)",
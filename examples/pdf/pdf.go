@@ -6,10 +6,9 @@ import (
 	"log"
 	"sync"
 
+	"gioui.org/f32"
 	"gioui.org/layout"
 	"gioui.org/op/paint"
-	"gioui.org/unit"
-	"gioui.org/widget"
 	"gioui.org/widget/material"
 	"github.com/dejadejade/giox/fn"
 )
@@ -29,7 +28,6 @@ type pdfPage struct {
 }
 
 type PDFDocument struct {
-	list  *layout.List
 	pages []*pdfPage
 	mutex sync.Mutex
 
@@ -40,13 +38,19 @@ type PDFDocument struct {
 	data   []byte
 }
 
+func init() {
+	if err := fn.Define("page", "inset(4);border(1px,1px,1px,1px,a0a0a0);inset(4)"); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func NewPDFDocument(file string, notify func()) (*PDFDocument, error) {
-	pdf := &PDFDocument{file: file, notify: notify, list: &layout.List{Axis: layout.Vertical, Alignment: layout.Middle}}
+	pdf := &PDFDocument{file: file, notify: notify}
 	return pdf, pdf.load()
 }
 
 func NewPDFDocumentWithData(data []byte, notify func()) (*PDFDocument, error) {
-	pdf := &PDFDocument{data: data, notify: notify, list: &layout.List{Axis: layout.Vertical, Alignment: layout.Middle}}
+	pdf := &PDFDocument{data: data, notify: notify}
 	return pdf, pdf.load()
 }
 
@@ -72,31 +76,27 @@ func (pdf *PDFDocument) unloadPage(idx int) {
 }
 
 func (pdf *PDFDocument) Layout(gtx C) D {
-	w, h := gtx.Constraints.Max.X, gtx.Constraints.Max.Y
+	h := gtx.Constraints.Max.Y
 	page := func(gtx C, idx int) D {
 		if page, err := pdf.Page(idx); err == nil {
 			return fn.Format(gtx, "hflex;dir(center);inset(4)",
-				fn.Child(";inset(4);border(1,1,1,1,a0a0a0);inset(4)", func(gtx C) D {
-					return page.Layout(gtx, w, h)
+				fn.Child(";.page", func(gtx C) D {
+					return page.Layout(gtx, h)
 				}))
 		}
 		return D{}
 	}
 
-	npages := pdf.NumPages()
-	view := func(gtx C) D {
-		return pdf.list.Layout(gtx, npages, page)
-	}
-
-	dims := view(gtx)
-	for i := 0; i < pdf.list.Position.First-1; i++ {
+	list := fn.List{Axis: layout.Vertical, Alignment: layout.Middle, Key: pdf, Scrollbar: true}
+	dims := list.Layout(gtx, pdf.NumPages(), page)
+	for i := 0; i < fn.ListState(pdf).Position.First-1; i++ {
 		pdf.unloadPage(i)
 	}
 
 	return dims
 }
 
-func (page *pdfPage) Layout(gtx C, w, h int) D {
+func (page *pdfPage) Layout(gtx C, h int) D {
 	if page.img == nil && !page.loading {
 		page.loading = true
 		go func() {
@@ -121,24 +121,14 @@ func (page *pdfPage) Layout(gtx C, w, h int) D {
 		return material.Caption(th, s).Layout(gtx)
 	}
 
-	mw, mh := int(w/px(gtx, 1)), int(h/px(gtx, 1))
-	op := paint.NewImageOp(page.img)
-
-	var scale float32 = 1.0
-	sz := op.Size()
-	if sz.X > mw {
-		scale = float32(mw) / float32(sz.X)
-	}
-	if sz.Y > mh {
-		s := float32(mh) / float32(sz.Y)
-		if scale > s {
-			scale = s
-		}
-	}
-
-	return widget.Image{Src: op, Scale: scale}.Layout(gtx)
-}
-
-func px(gtx C, x int) int {
-	return gtx.Px(unit.Dp(float32(x)))
+	img := paint.NewImageOp(page.img)
+	sz := img.Size()
+	gtx.Constraints.Max.Y = h
+	// Show the page at one dp per pixel, shrunk to fit the window.
+	return fn.Styled(func(gtx C) D {
+		size := gtx.Constraints.Min
+		img.Add(gtx.Ops)
+		paint.PaintOp{Rect: f32.Rectangle{Max: layout.FPt(size)}}.Add(gtx.Ops)
+		return D{Size: size}
+	}, fn.MaxSize(float32(sz.X), float32(sz.Y)), fn.Aspect(float32(sz.X)/float32(sz.Y)))(gtx)
 }
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var classes = struct {
+	sync.RWMutex
+	m map[string]string
+	// sheets maps the classes defined by stylesheets to their sheet.
+	sheets map[string]*Stylesheet
+}{m: make(map[string]string), sheets: make(map[string]*Stylesheet)}
+
+// Define names a chain of styling directives so that style strings can
+// use it as .name, for example
+//
+//	fn.Define("card", "inset(8);border(1,1,1,1,$outline);inset(4)")
+//	fn.Format(gtx, "vflex;.card;bkground($surface)", ...)
+//
+// A class may use other classes, which must already be defined.
+// Redefining a class is allowed, unless it would introduce a cycle or
+// the class comes from a stylesheet.
+// Styles are compiled against the classes defined at the time, so
+// defining a class discards every cached Program; Programs retained by
+// FormatF and WidgetF keep their previous expansion.
+func Define(name, style string) error {
+	if !isName(name) {
+		return &SyntaxError{Style: style, Name: name, Msg: "invalid class name"}
+	}
+	c := &compiler{style: style, defs: map[string]string{name: style}}
+	c.expanding = []string{name}
+	c.classBody()
+	if c.err != nil {
+		return c.err
+	}
+
+	classes.Lock()
+	if classes.sheets[name] != nil {
+		classes.Unlock()
+		return &SyntaxError{Style: style, Name: name, Msg: "class defined by a stylesheet"}
+	}
+	classes.m[name] = style
+	classes.Unlock()
+
+	resetPrograms()
+	return nil
+}
+
+// class returns the style defined for name.
+func (c *compiler) class(name string) (string, bool) {
+	if style, ok := c.defs[name]; ok {
+		return style, true
+	}
+	classes.RLock()
+	defer classes.RUnlock()
+	style, ok := classes.m[name]
+	return style, ok
+}
+
+// classBody compiles the style of a class, which consists of directives
+// and other classes only.
+func (c *compiler) classBody() []directive {
+	var chain []directive
+	secs, _ := c.sections()
+	for _, sec := range secs {
+		chain = c.chain(chain, sec)
+	}
+	return chain
+}
+
+// chain appends the directives of sec to chain, expanding classes.
+func (c *compiler) chain(chain []directive, sec section) []directive {
+	if sec.state != "" {
+		return c.states(chain, sec)
+	}
+	if !strings.HasPrefix(sec.name, ".") {
+		if d := c.directive(sec); d != nil {
+			chain = append(chain, d)
+		}
+		return chain
+	}
+	name := sec.name[1:]
+	if sec.params != nil {
+		c.errorf(sec.off, sec.name, "classes take no parameters")
+		return chain
+	}
+	for i, n := range c.expanding {
+		if n == name {
+			cycle := append(append([]string(nil), c.expanding[i:]...), name)
+			c.errorf(sec.off, sec.name, "class cycle %s", strings.Join(cycle, " -> "))
+			return chain
+		}
+	}
+	style, ok := c.class(name)
+	if !ok {
+		c.errorf(sec.off, sec.name, "undefined class")
+		return chain
+	}
+	sub := &compiler{style: style, defs: c.defs}
+	sub.expanding = append(c.expanding[:len(c.expanding):len(c.expanding)], name)
+	chain = append(chain, sub.classBody()...)
+	if sub.stateSec != nil && c.stateSec == nil {
+		c.stateSec = &sec
+	}
+	if sub.transSec != nil && c.transSec == nil {
+		c.transSec = &sec
+	}
+	for _, r := range sub.refs {
+		if r.class == "" {
+			r.off, r.sec, r.class = sec.off, sec.name, sec.name
+		}
+		c.refs = append(c.refs, r)
+	}
+	if sub.err != nil && c.err == nil {
+		err := *sub.err
+		err.Msg = fmt.Sprintf("in class %s: %s", sec.name, err.Msg)
+		c.err = &err
+	}
+	return chain
+}
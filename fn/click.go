@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// ClickKind is the kind of a ClickEvent.
+type ClickKind uint8
+
+const (
+	// Click is a press and release within the widget.
+	Click ClickKind = iota
+	// DoubleClick follows the Click of a second click in quick
+	// succession.
+	DoubleClick
+	// LongPress is a press held for a while. It is not followed by a
+	// Click.
+	LongPress
+)
+
+const (
+	doubleClickDuration = 300 * time.Millisecond
+	longPressDuration   = 500 * time.Millisecond
+)
+
+// ClickEvent is a click on a clickable widget.
+type ClickEvent struct {
+	Kind      ClickKind
+	Position  f32.Point
+	Source    pointer.Source
+	Modifiers key.Modifiers
+}
+
+// Clickable records the clicks on the widget it styles under tag, the
+// typed form of click(name), where tag may be any comparable value
+// other than nil.
+func Clickable(tag interface{}) Style {
+	if tag == nil {
+		panic("fn: Clickable with a nil tag")
+	}
+	return styleOf(clickS{tag})
+}
+
+// Clicked returns the clicks on the widget with key since the last
+// call. Call it before laying out the widget in each frame, as with
+// gesture.Click. A widget disabled with SetDisabled has no clicks.
+func Clicked(gtx C, key interface{}) []ClickEvent {
+	c := clickerFor(key)
+	if trackerFor(key).disabled {
+		// Drop the clicks pending from before the widget was disabled.
+		gtx.Events(c)
+		c.reset()
+		return nil
+	}
+	var clicks []ClickEvent
+	for _, e := range gtx.Events(c) {
+		e, ok := e.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case pointer.Press:
+			if c.pressed {
+				continue
+			}
+			c.pressed, c.long = true, false
+			c.pressAt, c.press = gtx.Now, e
+		case pointer.Release:
+			if !c.pressed {
+				continue
+			}
+			c.pressed = false
+			if c.long || !c.inside(e.Position) {
+				continue
+			}
+			click := ClickEvent{Kind: Click, Position: e.Position, Source: e.Source, Modifiers: e.Modifiers}
+			clicks = append(clicks, click)
+			if c.clicks == 1 && e.Time-c.last < doubleClickDuration {
+				click.Kind = DoubleClick
+				clicks = append(clicks, click)
+				c.clicks = 0
+			} else {
+				c.clicks = 1
+			}
+			c.last = e.Time
+		case pointer.Cancel:
+			c.pressed = false
+		}
+	}
+	if c.pressed && !c.long && !c.pressAt.IsZero() && gtx.Now.Sub(c.pressAt) >= longPressDuration {
+		c.long = true
+		p := c.press
+		clicks = append(clicks, ClickEvent{Kind: LongPress, Position: p.Position, Source: p.Source, Modifiers: p.Modifiers})
+	}
+	return clicks
+}
+
+// clicker is the state of a clickable widget.
+type clicker struct {
+	// size is the size of the widget at its last layout.
+	size image.Point
+	// pressed, pressAt and press describe the current press, and long
+	// whether it has been reported as a long press.
+	pressed bool
+	pressAt time.Time
+	press   pointer.Event
+	long    bool
+	// clicks counts the clicks towards a double click, the last of
+	// which was at last.
+	clicks int
+	last   time.Duration
+}
+
+// inside reports whether p is within the widget, where a press must be
+// released to click.
+func (c *clicker) inside(p f32.Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < float32(c.size.X) && p.Y < float32(c.size.Y)
+}
+
+// reset forgets the current press and the clicks towards a double
+// click.
+func (c *clicker) reset() {
+	c.pressed, c.long, c.clicks = false, false, 0
+	c.pressAt = time.Time{}
+}
+
+func clickerFor(key interface{}) *clicker {
+	return stateFor(clickState, key, func() interface{} { return new(clicker) }).(*clicker)
+}
+
+// clickS registers a pointer area over its widget for Clicked.
+type clickS struct {
+	key interface{}
+}
+
+func (s clickS) Layout(gtx C, w layout.Widget) D {
+	c := clickerFor(s.key)
+	m := op.Record(gtx.Ops)
+	dims := w(gtx)
+	call := m.Stop()
+	c.size = dims.Size
+	if trackerFor(s.key).disabled {
+		c.reset()
+		call.Add(gtx.Ops)
+		return dims
+	}
+	// The widget is nested in the area, so that its own handlers still
+	// receive events.
+	defer op.Push(gtx.Ops).Pop()
+	pointer.Rect(image.Rectangle{Max: dims.Size}).Add(gtx.Ops)
+	pointer.InputOp{Tag: c, Types: pointer.Press | pointer.Release | pointer.Cancel}.Add(gtx.Ops)
+	if c.pressed && !c.long && !c.pressAt.IsZero() {
+		// Wake up to report the long press.
+		op.InvalidateOp{At: c.pressAt.Add(longPressDuration)}.Add(gtx.Ops)
+	}
+	call.Add(gtx.Ops)
+	return dims
+}
+
+// click compiles click(name).
+func (c *compiler) click(s section) directive {
+	if name, ok := c.name(s); ok {
+		return clickS{name}
+	}
+	return nil
+}
@@ -0,0 +1,577 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gioui.org/layout"
+)
+
+// Program is a compiled style string. Compiling parses every section
+// once, so laying out a Program does no parsing and, once its internal
+// frames are warm, no allocation.
+//
+// The compiled form of a Program never changes, and a Program is safe
+// for concurrent use; the state of each layout is kept in pooled frames.
+type Program struct {
+	src   string
+	cont  Container
+	pre   Item
+	chain []directive
+	err   error
+
+	logged uint32
+
+	frames sync.Pool
+}
+
+// directive is a compiled styling section such as inset or border.
+type directive interface {
+	Layout(gtx C, w layout.Widget) D
+}
+
+// maxPrograms bounds the number of recently used Programs the cache
+// keeps, so that styles formatted from changing values do not pile up.
+const maxPrograms = 512
+
+// programs caches Programs in two generations. A Program used while its
+// generation is old moves to the current one, and the old generation is
+// dropped when the current one fills up, which keeps the Programs in use
+// and at most 2*maxPrograms in all.
+var programs = struct {
+	sync.RWMutex
+	m, old map[string]*Program
+}{m: make(map[string]*Program)}
+
+// Compile parses a style string into a Program. Programs are cached
+// process wide by their style string, so compiling the same style twice
+// returns the same Program while it is in use. A malformed style yields
+// a *SyntaxError.
+func Compile(style string) (*Program, error) {
+	p := lookup(style)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p, nil
+}
+
+// MustCompile is like Compile but panics if the style cannot be parsed.
+func MustCompile(style string) *Program {
+	p, err := Compile(style)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// lookup returns the cached Program for style, compiling it on first
+// use. Styles that fail to compile are cached too, so they are laid out
+// unstyled according to the error mode without being parsed again.
+func lookup(style string) *Program {
+	programs.RLock()
+	p := programs.m[style]
+	old := programs.old[style]
+	programs.RUnlock()
+	if p != nil {
+		return p
+	}
+	p = old
+	if p == nil {
+		p = compile(style)
+	}
+	programs.Lock()
+	defer programs.Unlock()
+	if q := programs.m[style]; q != nil {
+		return q
+	}
+	if len(programs.m) >= maxPrograms {
+		programs.old, programs.m = programs.m, make(map[string]*Program)
+	}
+	programs.m[style] = p
+	return p
+}
+
+func compile(style string) *Program {
+	return (&compiler{style: style}).program()
+}
+
+// program compiles the style of c. A style such as @name stands for the
+// style of that name in the loaded stylesheets.
+func (c *compiler) program() *Program {
+	p := &Program{src: c.style}
+	p.frames.New = func() interface{} { return newFrame(p) }
+	if strings.HasPrefix(c.style, "@") {
+		name := c.style[1:]
+		style, ok := c.named(name)
+		if !ok {
+			p.err = &SyntaxError{Style: c.style, Name: c.style, Msg: "undefined style"}
+			return p
+		}
+		c.style = style
+	}
+	secs, lead := c.sections()
+	// placing is set while the sections may place a child, which they do
+	// at the start of a child style or after its f, e or r prefix.
+	placing := lead
+	for i, sec := range secs {
+		if i == 0 && lead && sec.state == "" {
+			if cont, ok := c.container(sec); ok {
+				p.cont = cont
+				placing = false
+				continue
+			}
+			if pre, ok := c.prefix(sec); ok {
+				p.pre = pre
+				continue
+			}
+		}
+		if placing && sec.state == "" && c.place(sec, &p.pre) {
+			continue
+		}
+		placing = false
+		p.chain = c.chain(p.chain, sec)
+	}
+	adoptRadius(p.chain)
+	var keyed bool
+	if p.chain, keyed = interactive(p.chain); !keyed {
+		c.errorf(c.stateSec.off, c.stateSec.name, "state sections need a key(name)")
+	}
+	if p.chain, keyed = transitions(p.chain); !keyed {
+		c.errorf(c.transSec.off, c.transSec.name, "transitions need a key(name)")
+	}
+	if c.err != nil {
+		// Lay out malformed styles unstyled.
+		p.err = c.err
+		p.chain = nil
+	}
+	return p
+}
+
+// fail applies the error mode to err, logging at most once per Program.
+func (p *Program) fail(err error) {
+	switch currentErrorMode() {
+	case Strict:
+		panic(err)
+	case Log:
+		if atomic.CompareAndSwapUint32(&p.logged, 0, 1) {
+			log.Println(err)
+		}
+	}
+}
+
+// container returns the Program's container, falling back to a
+// vertical flex when the style has none.
+func (p *Program) container() Container {
+	if p.cont == nil {
+		return unstyled
+	}
+	return p.cont
+}
+
+var unstyled = flexC{axis: layout.Vertical}
+
+func (c *compiler) container(s section) (Container, bool) {
+	e, ok := lookupContainer(s.name)
+	if !ok {
+		return nil, false
+	}
+	if e.parse != nil {
+		return e.parse(c, s), true
+	}
+	v, ok := c.values(s, e.arity)
+	if !ok {
+		return nil, true
+	}
+	return e.factory(v), true
+}
+
+// prefix compiles the leading section of a child style. The weight of
+// e and r is accepted for symmetry with f and ignored.
+func (c *compiler) prefix(s section) (Item, bool) {
+	switch s.name {
+	case "f":
+		it := Item{Flexed: true, Weight: 1}
+		if c.arity(s, 0, 1) && len(s.params) == 1 {
+			it.Weight = c.float(s, 0)
+		}
+		return it, true
+	case "e":
+		c.arity(s, 0, 1)
+		return Item{Expanded: true}, true
+	case "r":
+		c.arity(s, 0, 1)
+		return Item{}, true
+	}
+	return Item{}, false
+}
+
+// place compiles the self, span and cell child prefixes into it, and
+// reports whether s is one of them.
+func (c *compiler) place(s section, it *Item) bool {
+	switch s.name {
+	case "self":
+		it.Align, it.Aligned = c.self(s)
+	case "span":
+		it.ColSpan, it.RowSpan = c.span(s)
+	case "cell":
+		it.Col, it.Row = c.cell(s)
+	default:
+		return false
+	}
+	return true
+}
+
+// self compiles the self(alignment) prefix.
+func (c *compiler) self(s section) (layout.Alignment, bool) {
+	if !c.arity(s, 1) {
+		return 0, false
+	}
+	p := s.params[0]
+	a, ok := alignmentFor(p.s)
+	if !ok || p.call {
+		c.errorf(p.off, s.name, "invalid alignment %q", p.s)
+	}
+	return a, ok
+}
+
+// directive compiles a styling section using the registered directives.
+func (c *compiler) directive(s section) directive {
+	e, ok := lookupDirective(s.name)
+	if !ok {
+		c.errorf(s.off, s.name, "unknown directive")
+		return nil
+	}
+	if e.parse != nil {
+		return e.parse(c, s)
+	}
+	v, ok := c.values(s, e.arity)
+	if !ok {
+		return nil
+	}
+	return e.factory(v)
+}
+
+var directions = []string{"nw", "n", "ne", "e", "se", "s", "sw", "w", "center"}
+
+// The built in directives and containers:
+//
+//	inset(all) or inset(left,top,right,bottom)
+//	size(width,height)
+//	border(left,top,right,bottom,color)
+//	bkground(color)
+//	dir(nw/n/ne/e/se/s/sw/w/center)
+//	rounded(size)
+//	radius(all) or radius(nw,ne,se,sw)
+//	width(w), height(h), minsize(width,height), maxsize(width,height),
+//	with sizes in dp or percent of the maximum constraint
+//	fill, fillx, filly
+//	aspect(ratio), such as aspect(16:9)
+//	shadow(dx,dy,blur,spread,color)
+//	elevation(n)
+//	lgradient(angle,color1,color2,...,stop1,stop2,...)
+//	rgradient(color1,color2,...,stop1,stop2,...), with optional stops in
+//	percent, one per color
+//	opacity(a)
+//	offset(x,y)
+//	scale(s) or scale(sx,sy)
+//	rotate(deg) or rotate(deg,origin)
+//	scroll(v/h/both,key(name),scrollbar)
+//	key(name)
+//	click(name)
+//	font(size,weight,style), with a size such as 14 (in sp) or caption,
+//	a weight normal/medium/bold and a style regular/italic
+//	color(color), align(start/center/end), maxlines(n), ellipsis,
+//	lineheight(x), applying to the Text within
+//	transition(bkground/size/opacity/offset/scale/all,duration,easing),
+//	with durations such as 200ms and easings linear, ease, easein,
+//	easeout or easeinout
+//	:hover, :pressed, :focused or :disabled before any of the above
+//
+//	hflex(alignment,spacing,gap(n)), vflex(alignment,spacing,gap(n)),
+//	stack(direction), grid(columns...,alignment,gap(col,row)),
+//	wrap(alignment,spacing,gap(h,v)),
+//	list(v/h,alignment,key(name),gap(n),scrollbar)
+//
+// with alignments start, middle, end or baseline and spacings start, end,
+// center, between, around or evenly.
+func init() {
+	l, f, col := LengthParam, FloatParam, ColorParam
+	registerDirective("inset", Arity{{l}, {l, l, l, l}}, func(v []Value) directive {
+		if len(v) == 1 {
+			return insetS{v[0].l, v[0].l, v[0].l, v[0].l}
+		}
+		return insetS{v[0].l, v[1].l, v[2].l, v[3].l}
+	})
+	registerDirective("size", Arity{{l, l}}, func(v []Value) directive {
+		return sizeS{v[0].l, v[1].l}
+	})
+	registerDirective("dir", Arity{{EnumParam(directions...)}}, func(v []Value) directive {
+		d, _ := directionFor(v[0].s)
+		return d
+	})
+	registerDirective("border", Arity{{l, l, l, l, col}}, func(v []Value) directive {
+		return borderS{v[0].l, v[1].l, v[2].l, v[3].l, v[4].c, corners{}}
+	})
+	registerDirective("rounded", Arity{{l}}, func(v []Value) directive {
+		return roundedS{v[0].l}
+	})
+	registerDirective("bkground", Arity{{col}}, func(v []Value) directive {
+		return backgroundS{v[0].c, corners{}}
+	})
+	registerDirective("radius", Arity{{l}, {l, l, l, l}}, func(v []Value) directive {
+		if len(v) == 1 {
+			return radiusS{corners{v[0].l, v[0].l, v[0].l, v[0].l}}
+		}
+		return radiusS{corners{v[0].l, v[1].l, v[2].l, v[3].l}}
+	})
+	registerDirective("shadow", Arity{{l, l, l, l, col}}, func(v []Value) directive {
+		return shadowS{v[0].l, v[1].l, v[2].l, v[3].l, v[4].c, corners{}}
+	})
+	registerDirectiveEntry("lgradient", directiveEntry{parse: func(c *compiler, s section) directive {
+		return c.gradient(s, false)
+	}})
+	registerDirectiveEntry("rgradient", directiveEntry{parse: func(c *compiler, s section) directive {
+		return c.gradient(s, true)
+	}})
+	registerDirective("opacity", Arity{{f}}, func(v []Value) directive {
+		return opacityS{v[0].f}
+	})
+	registerDirective("offset", Arity{{l, l}}, func(v []Value) directive {
+		return offsetS{v[0].l, v[1].l}
+	})
+	registerDirective("scale", Arity{{f}, {f, f}}, func(v []Value) directive {
+		if len(v) == 1 {
+			return scaleS{v[0].f, v[0].f}
+		}
+		return scaleS{v[0].f, v[1].f}
+	})
+	registerDirective("rotate", Arity{{f}, {f, EnumParam(directions...)}}, func(v []Value) directive {
+		if len(v) == 1 {
+			return rotateS{v[0].f, layout.Center}
+		}
+		d, _ := directionFor(v[1].s)
+		return rotateS{v[0].f, d}
+	})
+	e := Param{kind: extentParam}
+	registerDirective("width", Arity{{e}}, func(v []Value) directive {
+		return exactS{x: v[0].e}
+	})
+	registerDirective("height", Arity{{e}}, func(v []Value) directive {
+		return exactS{y: v[0].e}
+	})
+	registerDirective("minsize", Arity{{e, e}}, func(v []Value) directive {
+		return minSizeS{v[0].e, v[1].e}
+	})
+	registerDirective("maxsize", Arity{{e, e}}, func(v []Value) directive {
+		return maxSizeS{v[0].e, v[1].e}
+	})
+	registerDirective("fill", nil, func(v []Value) directive {
+		return fillS{x: true, y: true}
+	})
+	registerDirective("fillx", nil, func(v []Value) directive {
+		return fillS{x: true}
+	})
+	registerDirective("filly", nil, func(v []Value) directive {
+		return fillS{y: true}
+	})
+	registerDirective("aspect", Arity{{Param{kind: ratioParam}}}, func(v []Value) directive {
+		return aspectS{v[0].f}
+	})
+	registerDirective("elevation", Arity{{f}}, func(v []Value) directive {
+		return elevation(v[0].f)
+	})
+
+	registerContainer("hflex", containerEntry{parse: func(c *compiler, s section) Container {
+		return c.flex(layout.Horizontal, s)
+	}})
+	registerContainer("vflex", containerEntry{parse: func(c *compiler, s section) Container {
+		return c.flex(layout.Vertical, s)
+	}})
+	registerContainer("stack", containerEntry{parse: func(c *compiler, s section) Container {
+		return stackC{c.stack(s)}
+	}})
+	registerContainer("grid", containerEntry{parse: (*compiler).grid})
+	registerContainer("wrap", containerEntry{parse: (*compiler).wrap})
+	registerContainer("list", containerEntry{parse: (*compiler).list})
+	registerDirectiveEntry("scroll", directiveEntry{parse: (*compiler).scroll})
+	registerDirectiveEntry("key", directiveEntry{parse: (*compiler).interactive})
+	registerDirectiveEntry("click", directiveEntry{parse: (*compiler).click})
+	registerDirectiveEntry("transition", directiveEntry{parse: (*compiler).transition})
+	registerDirectiveEntry("font", directiveEntry{parse: (*compiler).font})
+	registerDirective("color", Arity{{col}}, func(v []Value) directive {
+		return textD{textStyle{set: colorProp, color: v[0].c}}
+	})
+	registerDirective("align", Arity{{EnumParam("start", "center", "end")}}, func(v []Value) directive {
+		return textD{textStyle{set: alignProp, align: textAligns[v[0].s]}}
+	})
+	registerDirective("maxlines", Arity{{f}}, func(v []Value) directive {
+		return textD{textStyle{set: maxLinesProp, maxLines: int(v[0].f)}}
+	})
+	registerDirective("ellipsis", nil, func(v []Value) directive {
+		return textD{textStyle{set: ellipsisProp, ellipsis: true}}
+	})
+	registerDirective("lineheight", Arity{{f}}, func(v []Value) directive {
+		return textD{textStyle{set: lineHeightProp, lineHeight: v[0].f}}
+	})
+}
+
+// frame holds the per-invocation state of a Program. Frames are pooled
+// so that nested and concurrent uses of the same Program do not share
+// state.
+type frame struct {
+	p *Program
+	// w is the innermost widget the chain is applied to.
+	w layout.Widget
+	// steps[i] lays out chain[i:] around w, and box lays out steps[0]
+	// as a box of the layout inspector while it is on.
+	steps []layout.Widget
+	box   layout.Widget
+	// body lays out the container around kids.
+	body layout.Widget
+
+	kids []Item
+	held []*frame
+
+	// n and build are the children of an Items child left for a lazy
+	// container to build, at index at among the children, and child
+	// lays out any child by index.
+	n, at int
+	build func(i int) ChildSpec
+	child func(gtx C, i int) D
+}
+
+func newFrame(p *Program) *frame {
+	f := &frame{p: p}
+	n := len(p.chain)
+	f.steps = make([]layout.Widget, n+1)
+	for i := 0; i < n; i++ {
+		i := i
+		f.steps[i] = func(gtx C) D {
+			return f.p.chain[i].Layout(gtx, f.steps[i+1])
+		}
+	}
+	f.steps[n] = func(gtx C) D {
+		return f.w(gtx)
+	}
+	f.box = func(gtx C) D {
+		if Debugging() && f.p.src != "" {
+			return debugBox(gtx, f.p, f.steps[0])
+		}
+		return f.steps[0](gtx)
+	}
+	f.body = func(gtx C) D {
+		if lc, ok := f.p.container().(lazyContainer); ok {
+			return lc.layoutLazy(gtx, len(f.kids)+f.n, f.child)
+		}
+		return f.p.container().Layout(gtx, f.kids)
+	}
+	f.child = func(gtx C, i int) D {
+		switch {
+		case i < f.at:
+			return f.kids[i].Widget(gtx)
+		case i < f.at+f.n:
+			return layoutChild(gtx, f.build(i-f.at))
+		default:
+			return f.kids[i-f.n].Widget(gtx)
+		}
+	}
+	return f
+}
+
+func (p *Program) get(w layout.Widget) *frame {
+	f := p.frames.Get().(*frame)
+	if w == nil {
+		w = empty
+	}
+	f.w = w
+	return f
+}
+
+func (p *Program) put(f *frame) {
+	for i, h := range f.held {
+		h.p.put(h)
+		f.held[i] = nil
+	}
+	for i := range f.kids {
+		f.kids[i] = Item{}
+	}
+	f.w = nil
+	f.n, f.at, f.build = 0, 0, nil
+	f.kids = f.kids[:0]
+	f.held = f.held[:0]
+	p.frames.Put(f)
+}
+
+// Widget lays out w styled by the Program.
+func (p *Program) Widget(gtx C, w layout.Widget) D {
+	if p.err != nil {
+		p.fail(p.err)
+	}
+	f := p.get(w)
+	dims := f.box(gtx)
+	p.put(f)
+	return dims
+}
+
+// Format lays out children in the container named by the Program's
+// first section, then styles the container by the remaining sections.
+func (p *Program) Format(gtx C, children ...ChildSpec) D {
+	if p.err != nil {
+		p.fail(p.err)
+	} else if p.cont == nil {
+		p.fail(&SyntaxError{Style: p.src, Msg: "missing container"})
+	}
+	f := p.get(nil)
+	f.w = f.body
+	_, lazy := p.container().(lazyContainer)
+	for _, child := range children {
+		if child.build == nil {
+			f.add(child)
+			continue
+		}
+		if lazy && f.build == nil {
+			f.n, f.at, f.build = child.n, len(f.kids), child.build
+			continue
+		}
+		for i := 0; i < child.n; i++ {
+			f.add(child.build(i))
+		}
+	}
+	dims := f.box(gtx)
+	p.put(f)
+	return dims
+}
+
+// add adds a child to be laid out by the container of the frame.
+func (f *frame) add(child ChildSpec) {
+	cp := lookup(child.style)
+	if cp.err != nil {
+		cp.fail(cp.err)
+	}
+	cf := cp.get(child.widget)
+	f.held = append(f.held, cf)
+	it := cp.pre
+	it.Widget = cf.box
+	f.kids = append(f.kids, it)
+}
+
+// layoutChild lays out a child built on demand.
+func layoutChild(gtx C, child ChildSpec) D {
+	cp := lookup(child.style)
+	if cp.err != nil {
+		cp.fail(cp.err)
+	}
+	cf := cp.get(child.widget)
+	dims := cf.box(gtx)
+	cp.put(cf)
+	return dims
+}
+
+// String returns the style the Program was compiled from.
+func (p *Program) String() string {
+	return p.src
+}
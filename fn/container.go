@@ -0,0 +1,279 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// Rigid returns a flex child laid out at its natural size, the typed
+// form of a child without a prefix.
+func Rigid(w layout.Widget) Item {
+	return Item{Widget: w}
+}
+
+// Flexed returns a flex child sharing the remaining space by weight, the
+// typed form of the f(weight) prefix.
+func Flexed(weight float32, w layout.Widget) Item {
+	return Item{Widget: w, Flexed: true, Weight: weight}
+}
+
+// Self overrides the cross axis alignment of a flex child, the typed
+// form of the self(alignment) prefix.
+func (it Item) Self(a layout.Alignment) Item {
+	it.Aligned, it.Align = true, a
+	return it
+}
+
+// Stacked returns a stack child laid out at its natural size.
+func Stacked(w layout.Widget) Item {
+	return Item{Widget: w}
+}
+
+// Expanded returns a stack child that fills the stack, the typed form
+// of the e prefix.
+func Expanded(w layout.Widget) Item {
+	return Item{Widget: w, Expanded: true}
+}
+
+// HFlex is the typed form of hflex(alignment,spacing,gap(n)).
+type HFlex struct {
+	Alignment layout.Alignment
+	Spacing   layout.Spacing
+	// Gap is the space between children in dp.
+	Gap float32
+}
+
+func (f HFlex) Layout(gtx C, items ...Item) D {
+	return flexC{layout.Horizontal, f.Spacing, f.Alignment, dp(f.Gap)}.Layout(gtx, items)
+}
+
+// VFlex is the typed form of vflex(alignment,spacing,gap(n)).
+type VFlex struct {
+	Alignment layout.Alignment
+	Spacing   layout.Spacing
+	// Gap is the space between children in dp.
+	Gap float32
+}
+
+func (f VFlex) Layout(gtx C, items ...Item) D {
+	return flexC{layout.Vertical, f.Spacing, f.Alignment, dp(f.Gap)}.Layout(gtx, items)
+}
+
+// Stack is the typed form of stack(direction).
+type Stack struct {
+	Alignment layout.Direction
+}
+
+func (s Stack) Layout(gtx C, items ...Item) D {
+	return stackC{layout.Stack{Alignment: s.Alignment}}.Layout(gtx, items)
+}
+
+// flexC is a layout.Flex with gaps between children and per child
+// alignment.
+type flexC struct {
+	axis    layout.Axis
+	spacing layout.Spacing
+	align   layout.Alignment
+	gap     length
+}
+
+type flexChild struct {
+	call op.CallOp
+	dims D
+}
+
+// Layout follows layout.Flex: Rigid children are laid out before
+// Flexed children, which share the space left by weight.
+func (c flexC) Layout(gtx C, items []Item) D {
+	var buf [16]flexChild
+	children := buf[:0]
+	if len(items) > len(buf) {
+		children = make([]flexChild, 0, len(items))
+	}
+	children = children[:len(items)]
+
+	cs := gtx.Constraints
+	mainMin, mainMax := c.main(cs.Min), c.main(cs.Max)
+	crossMin, crossMax := c.cross(cs.Min), c.cross(cs.Max)
+	if len(items) == 0 {
+		return D{Size: c.point(mainMin, 0)}
+	}
+	gap := 0
+	if len(items) > 1 {
+		gap = c.gap.px(gtx)
+	}
+	size := gap * (len(items) - 1)
+	remaining := mainMax - size
+	if remaining < 0 {
+		remaining = 0
+	}
+	var totalWeight float32
+	for i, it := range items {
+		if it.Flexed {
+			totalWeight += it.Weight
+			continue
+		}
+		m := op.Record(gtx.Ops)
+		gtx := gtx
+		gtx.Constraints = c.constraints(0, remaining, crossMin, crossMax)
+		dims := it.Widget(gtx)
+		children[i] = flexChild{m.Stop(), dims}
+		sz := c.main(dims.Size)
+		size += sz
+		if remaining -= sz; remaining < 0 {
+			remaining = 0
+		}
+	}
+	// fraction is the rounding error from a Flex weighting.
+	var fraction float32
+	flexTotal := remaining
+	for i, it := range items {
+		if !it.Flexed {
+			continue
+		}
+		var flexSize int
+		if remaining > 0 && totalWeight > 0 {
+			childSize := float32(flexTotal) * it.Weight / totalWeight
+			flexSize = int(childSize + fraction + .5)
+			fraction = childSize - float32(flexSize)
+			if flexSize > remaining {
+				flexSize = remaining
+			}
+		}
+		m := op.Record(gtx.Ops)
+		gtx := gtx
+		gtx.Constraints = c.constraints(flexSize, flexSize, crossMin, crossMax)
+		dims := it.Widget(gtx)
+		children[i] = flexChild{m.Stop(), dims}
+		sz := c.main(dims.Size)
+		size += sz
+		if remaining -= sz; remaining < 0 {
+			remaining = 0
+		}
+	}
+
+	var maxCross, maxBaseline int
+	for _, ch := range children {
+		if v := c.cross(ch.dims.Size); v > maxCross {
+			maxCross = v
+		}
+		if b := ch.dims.Size.Y - ch.dims.Baseline; b > maxBaseline {
+			maxBaseline = b
+		}
+	}
+	var space int
+	if mainMin > size {
+		space = mainMin - size
+	}
+	n := len(children)
+	var pos int
+	switch c.spacing {
+	case layout.SpaceSides:
+		pos += space / 2
+	case layout.SpaceStart:
+		pos += space
+	case layout.SpaceEvenly:
+		pos += space / (1 + n)
+	case layout.SpaceAround:
+		pos += space / (n * 2)
+	}
+	for i, ch := range children {
+		align := c.align
+		if items[i].Aligned {
+			align = items[i].Align
+		}
+		dims := ch.dims
+		var cross int
+		switch align {
+		case layout.End:
+			cross = maxCross - c.cross(dims.Size)
+		case layout.Middle:
+			cross = (maxCross - c.cross(dims.Size)) / 2
+		case layout.Baseline:
+			if c.axis == layout.Horizontal {
+				cross = maxBaseline - (dims.Size.Y - dims.Baseline)
+			}
+		}
+		stack := op.Push(gtx.Ops)
+		op.Offset(layout.FPt(c.point(pos, cross))).Add(gtx.Ops)
+		ch.call.Add(gtx.Ops)
+		stack.Pop()
+		pos += c.main(dims.Size)
+		if i < n-1 {
+			pos += gap
+			switch c.spacing {
+			case layout.SpaceEvenly:
+				pos += space / (1 + n)
+			case layout.SpaceAround:
+				pos += space / n
+			case layout.SpaceBetween:
+				pos += space / (n - 1)
+			}
+		}
+	}
+	switch c.spacing {
+	case layout.SpaceSides:
+		pos += space / 2
+	case layout.SpaceEnd:
+		pos += space
+	case layout.SpaceEvenly:
+		pos += space / (1 + n)
+	case layout.SpaceAround:
+		pos += space / (n * 2)
+	case layout.SpaceBetween:
+		if n == 1 {
+			pos += space
+		}
+	}
+	sz := c.point(pos, maxCross)
+	return D{Size: sz, Baseline: sz.Y - maxBaseline}
+}
+
+func (c flexC) main(p image.Point) int {
+	if c.axis == layout.Horizontal {
+		return p.X
+	}
+	return p.Y
+}
+
+func (c flexC) cross(p image.Point) int {
+	if c.axis == layout.Horizontal {
+		return p.Y
+	}
+	return p.X
+}
+
+func (c flexC) point(main, cross int) image.Point {
+	if c.axis == layout.Horizontal {
+		return image.Pt(main, cross)
+	}
+	return image.Pt(cross, main)
+}
+
+func (c flexC) constraints(mainMin, mainMax, crossMin, crossMax int) layout.Constraints {
+	return layout.Constraints{
+		Min: c.point(mainMin, crossMin),
+		Max: c.point(mainMax, crossMax),
+	}
+}
+
+type stackC struct {
+	stack layout.Stack
+}
+
+func (c stackC) Layout(gtx C, items []Item) D {
+	var buf [16]layout.StackChild
+	children := buf[:0]
+	for _, it := range items {
+		if it.Expanded {
+			children = append(children, layout.Expanded(it.Widget))
+		} else {
+			children = append(children, layout.Stacked(it.Widget))
+		}
+	}
+	return c.stack.Layout(gtx, children...)
+}
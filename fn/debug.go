@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+var debugMode int32
+
+func init() {
+	if os.Getenv("GIOX_DEBUG") != "" {
+		debugMode = 1
+	}
+}
+
+// SetDebug turns the layout inspector on or off. While on, every box
+// laid out by a style string is outlined, with its insets in green, its
+// borders in orange and the maximum of its constraints in purple when
+// highlighted. Hovering a box highlights it and shows its style,
+// dimensions and constraints next to the pointer; clicking pins the
+// box until it is clicked again. The inspector starts on if the
+// environment variable GIOX_DEBUG is set.
+func SetDebug(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&debugMode, v)
+}
+
+// Debugging reports whether the layout inspector is on.
+func Debugging() bool {
+	return atomic.LoadInt32(&debugMode) != 0
+}
+
+var (
+	debugOutline   = color.RGBA{R: 0xc0, A: 0xc0}
+	debugHighlight = color.RGBA{R: 0x05, G: 0x17, B: 0x28, A: 0x50}
+	debugInset     = color.RGBA{R: 0x30, G: 0x60, B: 0x20, A: 0x60}
+	debugBorder    = color.RGBA{R: 0x70, G: 0x40, A: 0x70}
+	debugLimit     = color.RGBA{R: 0x80, B: 0x80, A: 0xc0}
+	debugPanel     = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xe0}
+	debugText      = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// debugTag identifies the pointer area of a box by its number in the
+// frame, so that it stays the same across frames of a stable layout.
+type debugTag int
+
+// debugState is the state of the inspector. Boxes are numbered in
+// layout order from the start of each frame, which begins with the
+// first outermost box of a new Context time.
+var debugState struct {
+	sync.Mutex
+	frame time.Time
+	n     int
+	// hover and pinned are the numbers of the hovered and clicked boxes,
+	// or -1, and press the box pressed in this frame.
+	hover, pinned, press int
+	// info describes the hovered or pinned box, and pos is the pointer
+	// position in the outermost box that contains it.
+	info string
+	pos  f32.Point
+}
+
+func init() {
+	debugState.hover, debugState.pinned, debugState.press = -1, -1, -1
+}
+
+// debugBox lays out the box of p around w for the inspector.
+func debugBox(gtx C, p *Program, w layout.Widget) D {
+	q := queueOf(gtx)
+	root := !q.boxed
+	q.boxed = true
+	cs := gtx.Constraints
+
+	s := &debugState
+	s.Lock()
+	if root && (gtx.Now.IsZero() || !gtx.Now.Equal(s.frame)) {
+		s.frame, s.n, s.info = gtx.Now, 0, ""
+	}
+	first := s.n
+	n := s.n
+	s.n++
+	tag := debugTag(n)
+	for _, e := range gtx.Events(tag) {
+		e, ok := e.(pointer.Event)
+		if !ok {
+			continue
+		}
+		if root {
+			s.pos = e.Position
+		}
+		// The boxes containing the pointer receive its events in layout
+		// order, so the innermost box claims them last.
+		switch e.Type {
+		case pointer.Enter, pointer.Move:
+			s.hover = n
+		case pointer.Leave:
+			if s.hover == n {
+				s.hover = -1
+			}
+		case pointer.Press:
+			s.press = n
+		}
+	}
+	s.Unlock()
+
+	m := op.Record(gtx.Ops)
+	dims := withStyle(gtx, q, w)
+	call := m.Stop()
+
+	stack := op.Push(gtx.Ops)
+	pointer.Rect(image.Rectangle{Max: dims.Size}).Add(gtx.Ops)
+	pointer.InputOp{Tag: tag, Types: pointer.Enter | pointer.Leave | pointer.Move | pointer.Press}.Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	stack.Pop()
+
+	s.Lock()
+	defer s.Unlock()
+	pinned := s.pinned
+	if s.press >= 0 {
+		// A press pins its box, or unpins it if pinned.
+		if pinned == s.press {
+			pinned = -1
+		} else {
+			pinned = s.press
+		}
+	}
+	if root {
+		s.pinned, s.press = pinned, -1
+	}
+	shown := pinned
+	if shown < 0 {
+		shown = s.hover
+	}
+	sz := layout.FPt(dims.Size)
+	if n == shown {
+		s.info = fmt.Sprintf("%s\nsize %dx%d, baseline %d\nconstraints %dx%d to %dx%d",
+			debugStyle(p), dims.Size.X, dims.Size.Y, dims.Baseline,
+			cs.Min.X, cs.Min.Y, cs.Max.X, cs.Max.Y)
+		drawRect(gtx.Ops, 0, 0, sz.X, sz.Y, debugHighlight)
+		const limit = 1 << 15
+		max := cs.Max
+		if max.X > limit {
+			max.X = limit
+		}
+		if max.Y > limit {
+			max.Y = limit
+		}
+		debugFrame(gtx.Ops, layout.FPt(max), debugLimit)
+	}
+	debugFrame(gtx.Ops, sz, debugOutline)
+	if root && shown >= first && shown < s.n && s.info != "" {
+		debugInfo(gtx, s.info, s.pos, dims.Size)
+	}
+	return dims
+}
+
+// debugStyle returns the style of p as shown by the inspector.
+func debugStyle(p *Program) string {
+	if p.err != nil {
+		return p.src + " (malformed)"
+	}
+	return p.src
+}
+
+// debugFrame draws a 1px outline around a box of size sz.
+func debugFrame(ops *op.Ops, sz f32.Point, col color.RGBA) {
+	drawRect(ops, 0, 0, sz.X, 1, col)
+	drawRect(ops, 0, sz.Y-1, sz.X, 1, col)
+	drawRect(ops, 0, 1, 1, sz.Y-2, col)
+	drawRect(ops, sz.X-1, 1, 1, sz.Y-2, col)
+}
+
+// debugEdges shades the edges of a box of size sz, such as its insets.
+func debugEdges(gtx C, sz image.Point, left, top, right, bottom int, col color.RGBA) {
+	w, h := float32(sz.X), float32(sz.Y)
+	l, t, r, b := float32(left), float32(top), float32(right), float32(bottom)
+	if t > 0 {
+		drawRect(gtx.Ops, 0, 0, w, t, col)
+	}
+	if b > 0 {
+		drawRect(gtx.Ops, 0, h-b, w, b, col)
+	}
+	if l > 0 {
+		drawRect(gtx.Ops, 0, t, l, h-t-b, col)
+	}
+	if r > 0 {
+		drawRect(gtx.Ops, w-r, t, r, h-t-b, col)
+	}
+}
+
+// debugInfo draws the description of a box in a panel next to pos,
+// kept within a root box of size sz.
+func debugInfo(gtx C, info string, pos f32.Point, sz image.Point) {
+	const pad = 4
+	gtx.Constraints = layout.Constraints{Max: image.Pt(sz.X-2*pad, sz.Y)}
+	if gtx.Constraints.Max.X > 480 {
+		gtx.Constraints.Max.X = 480
+	}
+	if gtx.Constraints.Max.X <= 0 {
+		return
+	}
+	// The panel has a typography of its own.
+	q := styleQueue{Queue: queueOf(gtx).Queue, alpha: 1, boxed: true}
+	q.text = textStyle{set: sizeProp | colorProp, scale: typography["caption"], color: colorOf(debugText)}
+	m := op.Record(gtx.Ops)
+	dims := withStyle(gtx, q, label(info).Layout)
+	call := m.Stop()
+	if dims.Size == (image.Point{}) {
+		return
+	}
+	w, h := dims.Size.X+2*pad, dims.Size.Y+2*pad
+	x, y := int(pos.X)+12, int(pos.Y)+12
+	if x+w > sz.X {
+		x = sz.X - w
+	}
+	if y+h > sz.Y {
+		y = int(pos.Y) - h - 4
+	}
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+	defer op.Push(gtx.Ops).Pop()
+	op.Offset(f32.Point{X: float32(x), Y: float32(y)}).Add(gtx.Ops)
+	drawRect(gtx.Ops, 0, 0, float32(w), float32(h), debugPanel)
+	op.Offset(f32.Point{X: pad, Y: pad}).Add(gtx.Ops)
+	call.Add(gtx.Ops)
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// SyntaxError describes a malformed style string.
+type SyntaxError struct {
+	// Style is the style string being compiled.
+	Style string
+	// Offset is the byte offset in Style where the error was detected.
+	Offset int
+	// Name is the directive or container name, if known.
+	Name string
+	// Arity lists the accepted parameter counts of Name when the
+	// error is a parameter count mismatch.
+	Arity []int
+	// Msg describes the error.
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "fn: %q at offset %d", e.Style, e.Offset)
+	if e.Name != "" {
+		fmt.Fprintf(&b, " (%s)", e.Name)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// ErrorMode is the policy for style strings that fail to compile.
+type ErrorMode int32
+
+const (
+	// Log reports the error once and lays out the widget unstyled.
+	Log ErrorMode = iota
+	// Strict panics with the *SyntaxError. It is intended for tests.
+	Strict
+	// Ignore silently lays out the widget unstyled.
+	Ignore
+)
+
+var errorMode int32
+
+// SetErrorMode sets the policy for malformed style strings. The
+// default is Log.
+func SetErrorMode(m ErrorMode) {
+	atomic.StoreInt32(&errorMode, int32(m))
+}
+
+func currentErrorMode() ErrorMode {
+	return ErrorMode(atomic.LoadInt32(&errorMode))
+}
+
+// Validate reports whether style is well formed, returning a
+// *SyntaxError if it is not. Validate also reports theme references
+// undefined in the current Theme, which lay out as transparent or 0.
+func Validate(style string) error {
+	c := &compiler{style: style}
+	if p := c.program(); p.err != nil {
+		return p.err
+	}
+	t := CurrentTheme()
+	for _, r := range c.refs {
+		if t.defines(r.name, r.color) {
+			continue
+		}
+		kind := "value"
+		if r.color {
+			kind = "color"
+		}
+		msg := fmt.Sprintf("undefined theme %s $%s", kind, r.name)
+		if r.class != "" {
+			msg = fmt.Sprintf("in class %s: %s", r.class, msg)
+		}
+		return &SyntaxError{Style: style, Offset: r.off, Name: r.sec, Msg: msg}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestValidate(t *testing.T) {
+	th := NewTheme()
+	th.Colors["hint"] = rgb(0x808080)
+	th.Spacing["gap"] = 8
+	SetTheme(th)
+	defer SetTheme(nil)
+	if err := Define("validated", "inset($pad)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Define("hovered", ":hover bkground(fff)"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		classes.Lock()
+		delete(classes.m, "validated")
+		delete(classes.m, "hovered")
+		classes.Unlock()
+	}()
+	tests := []struct {
+		style  string
+		offset int
+		name   string
+		arity  []int
+	}{
+		{style: "hflex;border(0,0,0,1,a0b0c0);inset(8,16,8,8)", offset: -1},
+		{style: "f(1);inset(8, 16, 8, 8)", offset: -1},
+		{style: "vflex;", offset: -1},
+		{style: "hflex;inset(8,16,8)", offset: 6, name: "inset", arity: []int{1, 4}},
+		{style: ";bordr(0,0,0,1,a0b0c0)", offset: 1, name: "bordr"},
+		{style: "inset(8);size(1x,2)", offset: 14, name: "size"},
+		{style: "bkground(f2f2fz)", offset: 9, name: "bkground"},
+		{style: "hflex(midle)", offset: 6, name: "hflex"},
+		{style: "dir(e", offset: 5, name: "dir"},
+		{style: "hflex(middle,end,gap(4))", offset: -1},
+		{style: "f(2);self(end);inset(4)", offset: -1},
+		{style: "vflex(gap(4,4))", offset: 6, name: "gap", arity: []int{1}},
+		{style: "hflex(pad(4))", offset: 6, name: "hflex"},
+		{style: "self(top)", offset: 5, name: "self"},
+		{style: "inset(4);self(end)", offset: 9, name: "self"},
+		{style: "width(5x%)", offset: 6, name: "width"},
+		{style: "aspect(16:)", offset: 7, name: "aspect"},
+		{style: "fill(1)", offset: 0, name: "fill", arity: []int{0}},
+		{style: "grid(120,1fr,auto,2.5fr,baseline,gap(4))", offset: -1},
+		{style: "f;span(2);cell(1,2);self(end)", offset: -1},
+		{style: "grid", offset: 0, name: "grid"},
+		{style: "grid(1xfr)", offset: 5, name: "grid"},
+		{style: "span(0)", offset: 5, name: "span"},
+		{style: "cell(1)", offset: 0, name: "cell", arity: []int{2}},
+		{style: "wrap(middle,center,gap(4,8))", offset: -1},
+		{style: "wrap(gap(1,2,3))", offset: 5, name: "gap", arity: []int{1, 2}},
+		{style: "list(h,middle,key(users),gap(4),scrollbar)", offset: -1},
+		{style: "list(key(a,b))", offset: 5, name: "list"},
+		{style: "list(baseline)", offset: 5, name: "list"},
+		{style: "list(v,gap(4))", offset: 0, name: "list"},
+		{style: "scroll(both,key(editor),scrollbar);inset(4)", offset: -1},
+		{style: "scroll(up)", offset: 7, name: "scroll"},
+		{style: "scroll(v,scrollbar)", offset: 0, name: "scroll"},
+		{style: "key(card);bkground(fff);:hover bkground(f0f0f0);:pressed scale(0.98)", offset: -1},
+		{style: "bkground(fff);:active bkground(f0f0f0)", offset: 14, name: ":active"},
+		{style: "inset(4);:hover", offset: 9, name: ":hover"},
+		{style: ":hover bkgrund(fff)", offset: 7, name: "bkgrund"},
+		{style: "inset(4);:hover bkground(fff)", offset: 9, name: ":hover"},
+		{style: "inset(4);.hovered", offset: 9, name: ".hovered"},
+		{style: "key(a);.hovered", offset: -1},
+		{style: "key(a,b)", offset: 0, name: "key", arity: []int{1}},
+		{style: "click(save);key(save);:pressed scale(0.98)", offset: -1},
+		{style: "click(rgb(0,0,0))", offset: 6, name: "click"},
+		{style: "key(a);transition(bkground,200ms,easeout);:hover bkground(f0f0f0)", offset: -1},
+		{style: "key(a);transition(all,0.3s);size(4,4)", offset: -1},
+		{style: "inset(2);transition(all,0.3s);size(4,4)", offset: 9, name: "transition"},
+		{style: "transition(color,200ms)", offset: 11, name: "transition"},
+		{style: "transition(size,fast)", offset: 16, name: "transition"},
+		{style: "transition(size,1s,bounce)", offset: 19, name: "transition"},
+		{style: "transition(size)", offset: 0, name: "transition", arity: []int{2, 3}},
+		{style: "font(caption,medium);color($hint);align(end);maxlines(2);ellipsis;lineheight(1.5)", offset: -1},
+		{style: "font(14sp,700,italic)", offset: -1},
+		{style: "font(huge)", offset: 5, name: "font"},
+		{style: "font(14,heavy)", offset: 8, name: "font"},
+		{style: "font(14,bold,oblique)", offset: 13, name: "font"},
+		{style: "align(left)", offset: 6, name: "align"},
+		{style: "inset($gap);bkground($hint)", offset: -1},
+		{style: "lgradient(90,red,blue,20%,80%)", offset: -1},
+		{style: "rgradient(red,blue,white,0%,50%,100%)", offset: -1},
+		{style: "lgradient(90,red,blue,20%)", offset: 0, name: "lgradient"},
+		{style: "lgradient(90,red,blue,80%,20%)", offset: 26, name: "lgradient"},
+		{style: "rgradient(red,blue,0%,120%)", offset: 22, name: "rgradient"},
+		{style: "lgradient", offset: 0, name: "lgradient", arity: []int{3}},
+		{style: "inset(4);bkground($surface)", offset: 18, name: "bkground"},
+		{style: "inset($hint)", offset: 6, name: "inset"},
+		{style: "hflex;.validated", offset: 6, name: ".validated"},
+	}
+	for _, test := range tests {
+		err := Validate(test.style)
+		if test.offset < 0 {
+			if err != nil {
+				t.Errorf("Validate(%q) = %v", test.style, err)
+			}
+			continue
+		}
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Validate(%q) = %v, want *SyntaxError", test.style, err)
+			continue
+		}
+		if serr.Offset != test.offset || serr.Name != test.name || !reflect.DeepEqual(serr.Arity, test.arity) {
+			t.Errorf("Validate(%q) = {Offset: %d, Name: %q, Arity: %v}, want {%d, %q, %v}",
+				test.style, serr.Offset, serr.Name, serr.Arity, test.offset, test.name, test.arity)
+		}
+	}
+}
+
+func TestErrorMode(t *testing.T) {
+	defer SetErrorMode(Log)
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Exact(image.Pt(800, 600))}
+
+	SetErrorMode(Ignore)
+	if dims := Widget(gtx, "inset(8);bordr(1,1,1,1,a0a0a0)", box); dims.Size != image.Pt(800, 600) {
+		t.Errorf("malformed style laid out %v, want the unstyled widget", dims.Size)
+	}
+
+	SetErrorMode(Strict)
+	defer func() {
+		if _, ok := recover().(*SyntaxError); !ok {
+			t.Error("Strict mode did not panic with a *SyntaxError")
+		}
+	}()
+	Widget(gtx, "inset(8);bordr(1,1,1,1,a0a0a0)", box)
+}
@@ -28,23 +28,30 @@ func Styled(w layout.Widget, styles ...Style) layout.Widget {
 	return w
 }
 
-func Size(width, height float32) Style {
+// styleOf adapts a directive to a Style, so that the typed API and
+// style strings share one implementation.
+func styleOf(d directive) Style {
 	return func(w layout.Widget) layout.Widget {
 		return func(gtx C) D {
-			return sizeS{width, height}.Layout(gtx, w)
+			return d.Layout(gtx, w)
 		}
 	}
 }
 
+// Size is the typed form of size(width,height).
+func Size(width, height float32) Style {
+	return styleOf(sizeS{dp(width), dp(height)})
+}
+
 type sizeS struct {
-	width, height float32
+	width, height length
 }
 
 func (s sizeS) Layout(gtx C, w layout.Widget) D {
-	width, height := s.width, s.height
+	width, height := s.width.value(), s.height.value()
 	cs := gtx.Constraints
-	if width > 0 {
-		ww := gtx.Px(unit.Dp(width))
+	if width.V > 0 {
+		ww := gtx.Px(width)
 		if ww < cs.Min.X {
 			ww = cs.Min.X
 		}
@@ -54,8 +61,8 @@ func (s sizeS) Layout(gtx C, w layout.Widget) D {
 		gtx.Constraints.Min.X = ww
 		gtx.Constraints.Max.X = ww
 	}
-	if height > 0 {
-		hh := gtx.Px(unit.Dp(height))
+	if height.V > 0 {
+		hh := gtx.Px(height)
 		if hh < cs.Min.Y {
 			hh = cs.Min.Y
 		}
@@ -74,30 +81,45 @@ func drawRect(ops *op.Ops, x, y, w, h float32, fillcolor color.RGBA) {
 	paint.PaintOp{Rect: r}.Add(ops)
 }
 
+// Border is the typed form of border(left,top,right,bottom,color).
 func Border(left, top, right, bottom float32, col color.RGBA) Style {
-	return func(w layout.Widget) layout.Widget {
-		return func(gtx C) D {
-			return borderS{left, top, right, bottom, col}.Layout(gtx, w)
-		}
-	}
+	return styleOf(borderS{dp(left), dp(top), dp(right), dp(bottom), colorOf(col), corners{}})
 }
 
 type borderS struct {
-	left, top, right, bottom float32
-	col                      color.RGBA
+	left, top, right, bottom length
+	col                      colorRef
+	r                        corners
 }
 
 func (s borderS) Layout(gtx C, widget layout.Widget) D {
+	dims := s.layout(gtx, widget)
+	if Debugging() {
+		debugEdges(gtx, dims.Size, s.left.px(gtx), s.top.px(gtx), s.right.px(gtx), s.bottom.px(gtx), debugBorder)
+	}
+	return dims
+}
+
+func (s borderS) layout(gtx C, widget layout.Widget) D {
 	m := op.Record(gtx.Ops)
 	dims := widget(gtx)
 	call := m.Stop()
 
 	ops := gtx.Ops
 
-	left, top, right, bottom := s.left, s.top, s.right, s.bottom
-	col := s.col
+	left, top, right, bottom := float32(s.left.px(gtx)), float32(s.top.px(gtx)), float32(s.right.px(gtx)), float32(s.bottom.px(gtx))
+	col := Fade(gtx, s.col.rgba())
 	defer op.Push(gtx.Ops).Pop()
 	w, h := float32(dims.Size.X), float32(dims.Size.Y)
+	if !s.r.isZero() {
+		outer := f32.Rectangle{Max: f32.Point{X: w, Y: h}}
+		inner := f32.Rect(left, top, w-right, h-bottom)
+		rr := s.r.px(gtx, outer.Max)
+		clipRing(ops, outer, rr, inner, rr.shrink(left, top, right, bottom))
+		drawRect(ops, 0, 0, w, h, col)
+		call.Add(gtx.Ops)
+		return dims
+	}
 	if left > 0 {
 		drawRect(ops, 0, 0, left, h, col)
 	}
@@ -119,7 +141,7 @@ func FillRect(col color.RGBA, sz image.Point) layout.Widget {
 	return func(gtx C) D {
 		w := gtx.Px(unit.Dp(float32(sz.X)))
 		h := gtx.Px(unit.Dp(float32(sz.Y)))
-		paint.ColorOp{Color: col}.Add(gtx.Ops)
+		paint.ColorOp{Color: Fade(gtx, col)}.Add(gtx.Ops)
 		paint.PaintOp{Rect: f32.Rectangle{
 			Max: f32.Point{
 				X: float32(w),
@@ -139,34 +161,105 @@ func Fill(col color.RGBA) layout.Widget {
 		dr := f32.Rectangle{
 			Max: f32.Point{X: float32(d.X), Y: float32(d.Y)},
 		}
-		paint.ColorOp{Color: col}.Add(gtx.Ops)
+		paint.ColorOp{Color: Fade(gtx, col)}.Add(gtx.Ops)
 		paint.PaintOp{Rect: dr}.Add(gtx.Ops)
 		return layout.Dimensions{Size: d}
 	}
 }
 
+// Inset is the typed form of inset(all).
+func Inset(all float32) Style {
+	return styleOf(insetS{dp(all), dp(all), dp(all), dp(all)})
+}
+
+// Margin4 is the typed form of inset(left,top,right,bottom).
+func Margin4(left, top, right, bottom float32) Style {
+	return styleOf(insetS{dp(left), dp(top), dp(right), dp(bottom)})
+}
+
+type insetS struct {
+	left, top, right, bottom length
+}
+
+func (s insetS) Layout(gtx C, w layout.Widget) D {
+	in := layout.Inset{
+		Left:   s.left.value(),
+		Top:    s.top.value(),
+		Right:  s.right.value(),
+		Bottom: s.bottom.value(),
+	}
+	dims := in.Layout(gtx, w)
+	if Debugging() {
+		debugEdges(gtx, dims.Size, s.left.px(gtx), s.top.px(gtx), s.right.px(gtx), s.bottom.px(gtx), debugInset)
+	}
+	return dims
+}
+
+// Direction is the typed form of dir(d).
+func Direction(d layout.Direction) Style {
+	return styleOf(d)
+}
+
+// Background is the typed form of bkground(color).
+func Background(col color.RGBA) Style {
+	return styleOf(backgroundS{colorOf(col), corners{}})
+}
+
 type backgroundS struct {
-	col color.RGBA
+	col colorRef
+	r   corners
 }
 
+// Layout is equivalent to a layout.Stack of an Expanded Fill and a
+// Stacked w, without allocating the Fill closure every frame.
 func (s backgroundS) Layout(gtx C, w layout.Widget) D {
-	return layout.Stack{}.Layout(gtx,
-		layout.Expanded(Fill(s.col)),
-		layout.Stacked(w),
-	)
+	call, dims := behind(gtx, w)
+	sz := dims.Size
+	stack := op.Push(gtx.Ops)
+	if !s.r.isZero() {
+		fsz := layout.FPt(sz)
+		clipRRect(gtx.Ops, f32.Rectangle{Max: fsz}, s.r.px(gtx, fsz))
+	}
+	drawRect(gtx.Ops, 0, 0, float32(sz.X), float32(sz.Y), Fade(gtx, s.col.rgba()))
+	stack.Pop()
+	call.Add(gtx.Ops)
+	return dims
 }
 
-func Rounded(r float32) Style {
-	return func(w layout.Widget) layout.Widget {
-		return func(gtx C) D {
-			sz := gtx.Px(unit.Dp(r))
-			cc := clipCircle{}
-			return cc.Layout(gtx, func(gtx C) D {
-				gtx.Constraints = layout.Exact(gtx.Constraints.Constrain(image.Point{X: sz, Y: sz}))
-				return w(gtx)
-			})
-		}
+// behind records w with no minimum size, and returns its dimensions
+// constrained to gtx, so that a background can be painted before the
+// recording is added.
+func behind(gtx C, w layout.Widget) (op.CallOp, D) {
+	m := op.Record(gtx.Ops)
+	cgtx := gtx
+	cgtx.Constraints.Min = image.Point{}
+	dims := w(cgtx)
+	call := m.Stop()
+
+	sz := gtx.Constraints.Constrain(dims.Size)
+	if dims.Baseline != 0 {
+		dims.Baseline += sz.Y - dims.Size.Y
 	}
+	dims.Size = sz
+	return call, dims
+}
+
+// Rounded is the typed form of rounded(size).
+func Rounded(r float32) Style {
+	return styleOf(roundedS{dp(r)})
+}
+
+type roundedS struct {
+	r length
+}
+
+func (s roundedS) Layout(gtx C, w layout.Widget) D {
+	sz := s.r.px(gtx)
+	cc := clipCircle{}
+	return cc.Layout(gtx, func(gtx C) D {
+		gtx.Constraints = layout.Exact(gtx.Constraints.Constrain(image.Point{X: sz, Y: sz}))
+		return w(gtx)
+	})
 }
 
 type clipCircle struct {
@@ -0,0 +1,1235 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/io/router"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+func box(gtx C) D {
+	return D{Size: gtx.Constraints.Constrain(image.Pt(48, 24))}
+}
+
+func formatRow(gtx C) D {
+	return Format(gtx, "hflex(middle);border(0,0,0,1,a0b0c0);inset(8,16,8,8)",
+		Child(";inset(8);rounded(36)", box),
+		Child("f;inset(8,0,0,0)", func(gtx C) D {
+			return Format(gtx, "vflex",
+				Child(";dir(w)", box),
+				Child(";inset(0,4,0,0);bkground(f2f2f2)", box),
+			)
+		}),
+	)
+}
+
+func formatGrid(gtx C) D {
+	return Format(gtx, "grid(auto,1fr,60,baseline,gap(4))",
+		Child("span(2)", box),
+		Child("cell(3,2)", box),
+		Child("", box),
+		Child("span(1,2)", box),
+		Child("", box),
+	)
+}
+
+func TestFormatAllocs(t *testing.T) {
+	defer Forget("allocs")
+	widget := func(style string) layout.Widget {
+		return func(gtx C) D {
+			return Widget(gtx, style, box)
+		}
+	}
+	format := func(style string) layout.Widget {
+		return func(gtx C) D {
+			return Format(gtx, style, Child("", box), Child("f", box))
+		}
+	}
+	// A running transition stays at its start, where the clock stops.
+	now := time.Unix(0, 0)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+	defer Forget("running")
+	running := func(gtx C) D {
+		if s := animatorFor("running").slot(0); s.set {
+			s.from, s.start = vec{}, now
+		}
+		return Widget(gtx, "key(running);transition(size,200ms);size(50,50)", box)
+	}
+	tests := []struct {
+		name string
+		w    layout.Widget
+	}{
+		{"flex", formatRow},
+		{"grid", formatGrid},
+		{"wrap", format("wrap(middle,center,gap(4,8))")},
+		{"stack", format("stack(se)")},
+		{"radius", widget("radius(8);border(1,1,1,1,000000);bkground(ffffff)")},
+		{"transforms", widget("offset(2,4);scale(1.5);rotate(30)")},
+		{"opacity", widget("opacity(0.5);bkground(ff0000)")},
+		{"shadow", widget("radius(4);shadow(1,2,6,1,000000)")},
+		{"faded shadow", widget("opacity(0.5);shadow(1,2,6,1,000000)")},
+		{"elevation", widget("elevation(4)")},
+		{"lgradient", widget("lgradient(45,red,blue)")},
+		{"rgradient", widget("rgradient(red,blue,white,0%,50%,100%)")},
+		{"states", widget("key(allocs);:hover bkground(f0f0f0);:pressed inset(2)")},
+		{"transition", widget("key(allocs);transition(all,200ms);opacity(0.5);scale(1.5);bkground(ff0000)")},
+		{"running transition", running},
+		{"text", Text("font(caption,bold);color(ff0000);align(center);lineheight(1.5)", "Hello, world")},
+		{"text directives", FormatF("vflex;font(h6);maxlines(2)", Child("", Text("", "Hello")), Child("", Text("color(0000ff)", "world")))},
+		{"sizes", widget("width(50%);maxsize(100,100);aspect(16:9)")},
+		{"list", format("list(v,middle,key(allocs),gap(4),scrollbar)")},
+		{"scroll", widget("scroll(both,key(allocs),scrollbar)")},
+		{"click", widget("click(allocs);inset(4)")},
+	}
+	var ops op.Ops
+	// Layouts carry their styles in the Queue, or on the side if it is
+	// nil.
+	for _, q := range []event.Queue{nil, new(router.Router)} {
+		gtx := layout.Context{Ops: &ops, Queue: q, Constraints: layout.Exact(image.Pt(800, 600))}
+		for _, test := range tests {
+			test.w(gtx)
+			allocs := testing.AllocsPerRun(100, func() {
+				ops.Reset()
+				test.w(gtx)
+			})
+			if allocs != 0 {
+				t.Errorf("%s allocated %v times per run with Queue %T, want 0", test.name, allocs, q)
+			}
+		}
+	}
+}
+
+func TestCompileCache(t *testing.T) {
+	p1, err := Compile("hflex;inset(8)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, _ := Compile("hflex;inset(8)")
+	if p1 != p2 {
+		t.Error("Compile returned distinct programs for the same style")
+	}
+	if _, err := Compile("hflex;insets(8)"); err == nil {
+		t.Error("Compile accepted an unknown directive")
+	}
+	// The cache keeps the Programs in use as styles come and go.
+	for i := 0; i < 4*maxPrograms; i++ {
+		lookup(fmt.Sprintf("inset(%d)", i))
+		if lookup("hflex;inset(8)") != p1 {
+			t.Fatal("Compile dropped a program in use")
+		}
+	}
+	programs.RLock()
+	n := len(programs.m) + len(programs.old)
+	programs.RUnlock()
+	if n > 2*maxPrograms {
+		t.Errorf("%d cached programs, want at most %d", n, 2*maxPrograms)
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	var ops op.Ops
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ops.Reset()
+		gtx := layout.Context{
+			Ops:         &ops,
+			Constraints: layout.Exact(image.Pt(800, 600)),
+		}
+		formatRow(gtx)
+	}
+}
+
+func BenchmarkWidget(b *testing.B) {
+	var ops op.Ops
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ops.Reset()
+		gtx := layout.Context{
+			Ops:         &ops,
+			Constraints: layout.Exact(image.Pt(800, 600)),
+		}
+		Widget(gtx, ";inset(4);border(1,1,1,1,a0a0a0);inset(4)", box)
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		compile("hflex(middle);border(0,0,0,1,a0b0c0);inset(8,16,8,8)")
+	}
+}
+
+func TestColors(t *testing.T) {
+	tests := []struct {
+		style string
+		col   color.RGBA
+	}{
+		{"bkground(a0b0c0)", color.RGBA{R: 0xa0, G: 0xb0, B: 0xc0, A: 0xff}},
+		{"bkground(#abc)", color.RGBA{R: 0xaa, G: 0xbb, B: 0xcc, A: 0xff}},
+		{"bkground(#a0b0c0)", color.RGBA{R: 0xa0, G: 0xb0, B: 0xc0, A: 0xff}},
+		{"bkground(#a0b0c080)", color.RGBA{R: 0x50, G: 0x58, B: 0x60, A: 0x80}},
+		{"bkground(#f008)", color.RGBA{R: 0x88, A: 0x88}},
+		{"bkground(rgba(160, 176, 192, 0.5))", color.RGBA{R: 0x50, G: 0x58, B: 0x60, A: 0x80}},
+		{"bkground(rgb(160,176,192))", color.RGBA{R: 0xa0, G: 0xb0, B: 0xc0, A: 0xff}},
+		{"bkground(Red)", color.RGBA{R: 0xff, A: 0xff}},
+		{"bkground(transparent)", color.RGBA{}},
+		{"border(0,0,0,1,rgba(0,0,0,1))", color.RGBA{A: 0xff}},
+	}
+	for _, test := range tests {
+		p, err := Compile(test.style)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", test.style, err)
+			continue
+		}
+		var col color.RGBA
+		switch d := p.chain[0].(type) {
+		case backgroundS:
+			col = d.col.rgba()
+		case borderS:
+			col = d.col.rgba()
+		}
+		if col != test.col {
+			t.Errorf("Compile(%q) color = %v, want %v", test.style, col, test.col)
+		}
+	}
+	for _, style := range []string{"bkground(#abcde)", "bkground(rgba(0,0,0))", "bkground(rgba(0,0,300,1))", "bkground(reddish)"} {
+		if err := Validate(style); err == nil {
+			t.Errorf("Validate(%q) accepted an invalid color", style)
+		}
+	}
+}
+
+func TestTheme(t *testing.T) {
+	defer SetTheme(nil)
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(800, 600)}}
+	style := "inset($gap);size($avatar,$avatar);bkground($surface)"
+	p := MustCompile(style)
+
+	th := NewTheme()
+	th.Spacing["gap"] = 8
+	th.Radii["avatar"] = 36
+	th.Colors["surface"] = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	SetTheme(th)
+	if err := Validate(style); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Widget(gtx, box).Size; got != image.Pt(52, 52) {
+		t.Errorf("themed size = %v, want (52,52)", got)
+	}
+	if got := p.chain[2].(backgroundS).col.rgba(); got != th.Colors["surface"] {
+		t.Errorf("$surface = %v, want %v", got, th.Colors["surface"])
+	}
+
+	dark := NewTheme()
+	dark.Spacing["gap"] = 16
+	dark.Radii["avatar"] = 48
+	SetTheme(dark)
+	if got := p.Widget(gtx, box).Size; got != image.Pt(80, 80) {
+		t.Errorf("size after switching themes = %v, want (80,80)", got)
+	}
+
+	if err := Validate("bkground($)"); err == nil {
+		t.Error("Validate accepted an empty theme reference")
+	}
+}
+
+func TestClasses(t *testing.T) {
+	defer func() {
+		classes.Lock()
+		classes.m = make(map[string]string)
+		classes.Unlock()
+	}()
+	if err := Define("pad", "inset(4)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Define("card", ".pad;border(1,1,1,1,a0a0a0);.pad"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Compile("hflex;.card;bkground(white);.pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(p.chain); n != 5 {
+		t.Errorf("expanded chain has %d directives, want 5", n)
+	}
+	if err := Define("pad", ".card"); err == nil {
+		t.Error("Define accepted a class cycle")
+	} else if msg := err.Error(); !strings.Contains(msg, "pad -> card -> pad") {
+		t.Errorf("cycle error %q does not name the cycle", msg)
+	}
+	if err := Validate(";.missing"); err == nil {
+		t.Error("Validate accepted an undefined class")
+	}
+	if err := Define("bad", "inset(1,2)"); err == nil {
+		t.Error("Define accepted a malformed style")
+	}
+}
+
+type firstC struct{}
+
+func (firstC) Layout(gtx C, items []Item) D {
+	if len(items) == 0 {
+		return D{}
+	}
+	return items[0].Widget(gtx)
+}
+
+func TestRegister(t *testing.T) {
+	var got color.RGBA
+	RegisterDirective("tint", Arity{{ColorParam}, {ColorParam, EnumParam("fg", "bg")}}, func(v []Value) Directive {
+		return DirectiveFunc(func(gtx C, w layout.Widget) D {
+			got = v[0].Color()
+			return w(gtx)
+		})
+	})
+	RegisterContainer("first", nil, func(v []Value) Container {
+		return firstC{}
+	})
+
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(800, 600)}}
+	dims := Format(gtx, "first;tint(#f00,bg);inset(1)", Child("", box), Child("", FillRect(color.RGBA{}, image.Pt(1, 1))))
+	if dims.Size != image.Pt(50, 26) {
+		t.Errorf("registered container laid out %v, want (50,26)", dims.Size)
+	}
+	if want := (color.RGBA{R: 0xff, A: 0xff}); got != want {
+		t.Errorf("registered directive got color %v, want %v", got, want)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		ops.Reset()
+		Widget(gtx, "tint(#f00);inset(1)", box)
+	})
+	if allocs != 0 {
+		t.Errorf("registered directive allocated %v times per run, want 0", allocs)
+	}
+	if err := Validate("first;tint(#f00,fg,1)"); err == nil {
+		t.Error("Validate accepted a registered directive with the wrong arity")
+	}
+	if err := Validate("tint(#f00,middle)"); err == nil {
+		t.Error("Validate accepted an invalid keyword")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a directive twice did not panic")
+		}
+	}()
+	RegisterDirective("inset", nil, nil)
+}
+
+// TestTypedParity checks that the typed API and style strings produce
+// identical ops.
+func TestTypedParity(t *testing.T) {
+	gray := color.RGBA{R: 0xa0, G: 0xa0, B: 0xa0, A: 0xff}
+	tests := []struct {
+		style string
+		typed []Style
+	}{
+		{"inset(8)", []Style{Inset(8)}},
+		{"inset(1,2,3,4)", []Style{Margin4(1, 2, 3, 4)}},
+		{"inset(1dp,2dp,3,4dp)", []Style{Margin4(1, 2, 3, 4)}},
+		{"size(40,30)", []Style{Size(40, 30)}},
+		{"dir(se)", []Style{Direction(layout.SE)}},
+		{"border(1,1,1,1,a0a0a0)", []Style{Border(1, 1, 1, 1, gray)}},
+		{"bkground(a0a0a0)", []Style{Background(gray)}},
+		{"rounded(36)", []Style{Rounded(36)}},
+		{"inset(4);border(1,1,1,1,a0a0a0);inset(4)", []Style{Inset(4), Border(1, 1, 1, 1, gray), Inset(4)}},
+		{"radius(1,2,3,4)", []Style{Radius4(1, 2, 3, 4)}},
+		{"bkground(a0a0a0);radius(8)", []Style{RoundedBackground(gray, Corners{8, 8, 8, 8}), Radius(8)}},
+		{"inset(2);border(1,1,1,1,a0a0a0);radius(6);bkground(a0a0a0)", []Style{
+			Inset(2), RoundedBorder(1, 1, 1, 1, gray, Corners{6, 6, 6, 6}), Radius(6), RoundedBackground(gray, Corners{6, 6, 6, 6}),
+		}},
+		{"bkground(a0a0a0);inset(50);radius(8)", []Style{Background(gray), Inset(50), Radius(8)}},
+		{"radius(8);size(40,40);border(1,1,1,1,a0a0a0)", []Style{Radius(8), Size(40, 40), Border(1, 1, 1, 1, gray)}},
+		{"shadow(2,4,8,1,a0a0a0)", []Style{Shadow(2, 4, 8, 1, gray)}},
+		{"elevation(6)", []Style{Elevation(6)}},
+		{"opacity(0.5);bkground(a0a0a0)", []Style{Opacity(.5), Background(gray)}},
+		{"offset(4,-2)", []Style{Offset(4, -2)}},
+		{"scale(2)", []Style{Scale(2)}},
+		{"scale(1,0.5)", []Style{ScaleXY(1, .5)}},
+		{"rotate(45)", []Style{Rotate(45)}},
+		{"rotate(-90,nw)", []Style{RotateAround(-90, layout.NW)}},
+		{"width(50%);height(20)", []Style{WidthPercent(50), Height(20)}},
+		{"minsize(10,20);maxsize(100,200)", []Style{MinSize(10, 20), MaxSize(100, 200)}},
+		{"fillx;filly;fill", []Style{FillX(), FillY(), FillXY()}},
+		{"aspect(16:9)", []Style{Aspect(16.0 / 9)}},
+		{"lgradient(90,a0a0a0,00000000)", []Style{LinearGradient(90, gray, color.RGBA{})}},
+		{"rgradient(a0a0a0,000000,a0a0a0)", []Style{RadialGradient(gray, color.RGBA{A: 0xff}, gray)}},
+		{"lgradient(45,a0a0a0,000000,25%,75%)", []Style{LinearGradientStops(45, Stop{.25, gray}, Stop{.75, color.RGBA{A: 0xff}})}},
+		{"lgradient(0,a0a0a0,000000);radius(4)", []Style{RoundedLinearGradient(Corners{4, 4, 4, 4}, 0, gray, color.RGBA{A: 0xff}), Radius(4)}},
+		{"scroll(both,key(parity),scrollbar)", []Style{Scroll(ScrollBoth, "parity", true)}},
+		{"key(parity);bkground(a0a0a0);:hover bkground(000000)", []Style{
+			Interactive("parity", Hovered), Background(gray), When(Hovered, Background(color.RGBA{A: 0xff})),
+		}},
+		{"shadow(0,2,4,0,a0a0a0);radius(8)", []Style{RoundedShadow(0, 2, 4, 0, gray, Corners{8, 8, 8, 8}), Radius(8)}},
+	}
+	cs := layout.Constraints{Max: image.Pt(800, 600)}
+	for _, test := range tests {
+		d1, ops1 := layoutOps(cs, WidgetF(test.style, box))
+		d2, ops2 := layoutOps(cs, Styled(box, test.typed...))
+		if d1 != d2 || !bytes.Equal(ops1, ops2) {
+			t.Errorf("%q: typed API laid out %v, style string %v", test.style, d2, d1)
+		}
+	}
+
+	d1, ops1 := layoutOps(cs, FormatF("hflex(middle)", Child("", box), Child("f(2)", box)))
+	d2, ops2 := layoutOps(cs, func(gtx C) D {
+		return HFlex{Alignment: layout.Middle}.Layout(gtx, Rigid(box), Flexed(2, box))
+	})
+	if d1 != d2 || !bytes.Equal(ops1, ops2) {
+		t.Errorf("HFlex laid out %v, hflex %v", d2, d1)
+	}
+	d1, ops1 = layoutOps(cs, FormatF("grid(100,1fr,auto,middle,gap(10,4))", Child("span(2)", box), Child("", box), Child("cell(3,3)", box)))
+	d2, ops2 = layoutOps(cs, func(gtx C) D {
+		g := Grid{Columns: []GridColumn{FixedColumn(100), FrColumn(1), AutoColumn()}, Alignment: layout.Middle, ColumnGap: 10, RowGap: 4}
+		return g.Layout(gtx, Rigid(box).Span(2, 1), Rigid(box), Rigid(box).Cell(3, 3))
+	})
+	if d1 != d2 || !bytes.Equal(ops1, ops2) {
+		t.Errorf("Grid laid out %v, grid %v", d2, d1)
+	}
+	d1, ops1 = layoutOps(cs, FormatF("wrap(baseline,center,gap(4,8))", Child("", box), Child("", box), Child("", box)))
+	d2, ops2 = layoutOps(cs, func(gtx C) D {
+		return Wrap{Alignment: layout.Baseline, Spacing: layout.SpaceSides, Gap: 4, LineGap: 8}.Layout(gtx, Rigid(box), Rigid(box), Rigid(box))
+	})
+	if d1 != d2 || !bytes.Equal(ops1, ops2) {
+		t.Errorf("Wrap laid out %v, wrap %v", d2, d1)
+	}
+	d1, ops1 = layoutOps(cs, FormatF("list(h,middle,key(parity1),gap(4),scrollbar)", Items(10, func(int) ChildSpec { return Child("", box) })))
+	d2, ops2 = layoutOps(cs, func(gtx C) D {
+		l := List{Axis: layout.Horizontal, Alignment: layout.Middle, Key: "parity2", Gap: 4, Scrollbar: true}
+		return l.Layout(gtx, 10, func(gtx C, i int) D { return box(gtx) })
+	})
+	if d1 != d2 || !bytes.Equal(ops1, ops2) {
+		t.Errorf("List laid out %v, list %v", d2, d1)
+	}
+	Forget("parity1")
+	Forget("parity2")
+	d1, ops1 = layoutOps(cs, FormatF("stack(se)", Child("e", box), Child("", box)))
+	d2, ops2 = layoutOps(cs, func(gtx C) D {
+		return Stack{Alignment: layout.SE}.Layout(gtx, Expanded(box), Stacked(box))
+	})
+	if d1 != d2 || !bytes.Equal(ops1, ops2) {
+		t.Errorf("Stack laid out %v, stack %v", d2, d1)
+	}
+}
+
+func TestShadow(t *testing.T) {
+	black := color.RGBA{A: 0xff}
+	k := shadowKey{size: image.Pt(40, 20), blur: 8, col: black}
+	img1, margin := shadowMask(k, 1)
+	img2, _ := shadowMask(k, 1)
+	if img1 != img2 {
+		t.Error("shadow mask not cached")
+	}
+	if margin != 12 {
+		t.Errorf("margin %d, want 12", margin)
+	}
+	// A faded shadow fades the cached mask.
+	faded1, _ := shadowMask(k, 0.5)
+	faded2, _ := shadowMask(k, 0.5)
+	if faded1 != faded2 || faded1 == img1 {
+		t.Error("faded shadow mask not cached apart from the mask")
+	}
+	mask := shadows.lookup(imageKey{shadow: k}, nil).src.(*image.RGBA)
+	fk := k
+	fk.fade = 0x80
+	faded := shadows.lookup(imageKey{shadow: fk}, nil).src.(*image.RGBA)
+	if a, fa := mask.RGBAAt(32, 22).A, faded.RGBAAt(32, 22).A; fa != uint8(float32(a)*0x80/0xff+.5) {
+		t.Errorf("faded center alpha %#x, want half of %#x", fa, a)
+	}
+
+	alpha := func(k shadowKey, x, y int) uint8 {
+		m := renderShadow(k, 0)
+		return m.RGBAAt(x, y).A
+	}
+	if a := alpha(k, 20, 10); a < 0xe0 {
+		t.Errorf("center alpha %#x, want opaque", a)
+	}
+	sharp := shadowKey{size: image.Pt(40, 20), col: black}
+	if a := alpha(sharp, 0, 0); a != 0xff {
+		t.Errorf("square corner alpha %#x, want 0xff", a)
+	}
+	sharp.r = rradii{8, 8, 8, 8}
+	if a := alpha(sharp, 0, 0); a != 0 {
+		t.Errorf("rounded corner alpha %#x, want 0", a)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	blue := color.RGBA{B: 0xff, A: 0xff}
+	k := gradientKey{size: image.Pt(100, 10), n: 2}
+	k.cols[0], k.cols[1] = red, blue
+	k.stops[1] = 1
+	img := renderGradient(k)
+	if c := img.RGBAAt(0, 5); c.R < 0xf0 || c.B > 0x10 {
+		t.Errorf("left edge %v, want red", c)
+	}
+	if c := img.RGBAAt(99, 5); c.B < 0xf0 || c.R > 0x10 {
+		t.Errorf("right edge %v, want blue", c)
+	}
+	k.angle = 90
+	img = renderGradient(k)
+	if c1, c2 := img.RGBAAt(0, 5), img.RGBAAt(99, 5); c1 != c2 {
+		t.Errorf("vertical gradient varies horizontally: %v, %v", c1, c2)
+	}
+
+	k = gradientKey{size: image.Pt(20, 20), radial: true, n: 2}
+	k.cols[0], k.cols[1] = red, color.RGBA{}
+	k.stops[1] = 1
+	img = renderGradient(k)
+	if c := img.RGBAAt(0, 0); c.A > 0x10 {
+		t.Errorf("radial corner %v, want transparent", c)
+	}
+	if c := img.RGBAAt(10, 10); c.A < 0xf0 {
+		t.Errorf("radial center %v, want opaque", c)
+	}
+	if c := k.at(.5); c.R > c.A {
+		t.Errorf("color %v is not premultiplied", c)
+	}
+
+	// Colors extend past the first and last stops.
+	k = gradientKey{size: image.Pt(100, 1), n: 2}
+	k.cols[0], k.cols[1] = red, blue
+	k.stops[0], k.stops[1] = .5, .75
+	img = renderGradient(k)
+	if c := img.RGBAAt(40, 0); c != red {
+		t.Errorf("before the first stop %v, want red", c)
+	}
+	if c := img.RGBAAt(62, 0); c.R < 0x70 || c.B < 0x70 {
+		t.Errorf("between the stops %v, want purple", c)
+	}
+	if c := img.RGBAAt(80, 0); c != blue {
+		t.Errorf("after the last stop %v, want blue", c)
+	}
+
+	// A gradient paints its colors as bkground does.
+	half, _ := parseColor(param{s: "#ff000080"})
+	k = gradientKey{size: image.Pt(4, 4), n: 2}
+	k.cols[0], k.cols[1] = half, half
+	if c := renderGradient(k).RGBAAt(2, 2); c != half {
+		t.Errorf("gradient of %v painted %v", half, c)
+	}
+}
+
+func TestTransformHitArea(t *testing.T) {
+	tag := new(int)
+	target := func(gtx C) D {
+		dims := box(gtx)
+		pointer.Rect(image.Rectangle{Max: dims.Size}).Add(gtx.Ops)
+		pointer.InputOp{Tag: tag, Types: pointer.Press}.Add(gtx.Ops)
+		return dims
+	}
+	tests := []struct {
+		style string
+		hit   f32.Point
+		miss  f32.Point
+	}{
+		{"offset(100,50)", f32.Point{X: 110, Y: 60}, f32.Point{X: 10, Y: 10}},
+		{"scale(0.5)", f32.Point{X: 24, Y: 12}, f32.Point{X: 4, Y: 4}},
+		{"rotate(90)", f32.Point{X: 24, Y: 0}, f32.Point{X: 2, Y: 12}},
+	}
+	for _, test := range tests {
+		var ops op.Ops
+		gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(800, 600)}}
+		Widget(gtx, test.style, target)
+		var r router.Router
+		r.Frame(&ops)
+		r.Events(tag)
+		press := func(p f32.Point) bool {
+			r.Add(
+				pointer.Event{Type: pointer.Press, Source: pointer.Mouse, Position: p},
+				pointer.Event{Type: pointer.Release, Source: pointer.Mouse, Position: p},
+			)
+			for _, e := range r.Events(tag) {
+				if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+					return true
+				}
+			}
+			return false
+		}
+		if !press(test.hit) {
+			t.Errorf("%s: press at %v missed", test.style, test.hit)
+		}
+		if press(test.miss) {
+			t.Errorf("%s: press at %v hit", test.style, test.miss)
+		}
+	}
+}
+
+func TestOpacity(t *testing.T) {
+	c := color.RGBA{R: 0x80, A: 0xff}
+	gtx := layout.Context{Ops: new(op.Ops)}
+	if got := Fade(gtx, c); got != c {
+		t.Errorf("unfaded color %v, want %v", got, c)
+	}
+	Styled(func(gtx C) D {
+		Styled(func(gtx C) D {
+			if got, want := Fade(gtx, c), (color.RGBA{R: 0x20, A: 0x40}); got != want {
+				t.Errorf("faded color %v, want %v", got, want)
+			}
+			if gtx.Queue != nil {
+				t.Error("opacity enabled a disabled widget")
+			}
+			return D{}
+		}, Opacity(.5))(gtx)
+		return D{}
+	}, Opacity(.5))(gtx)
+
+	// Widgets disabled within opacity are faded too.
+	gtx.Queue = new(router.Router)
+	Styled(func(gtx C) D {
+		if got, want := Fade(gtx.Disabled(), c), (color.RGBA{R: 0x40, A: 0x80}); got != want {
+			t.Errorf("faded color %v, want %v", got, want)
+		}
+		return D{}
+	}, Opacity(.5))(gtx)
+	for _, s := range styleStacks.m {
+		if s.n != 0 {
+			t.Errorf("%d styles left on a stack after layout", s.n)
+		}
+	}
+}
+
+func TestFlex(t *testing.T) {
+	cs := layout.Exact(image.Pt(400, 100))
+	flexes := []struct {
+		style string
+		flex  layout.Flex
+	}{
+		{"hflex(between)", layout.Flex{Spacing: layout.SpaceBetween}},
+		{"hflex(middle,around)", layout.Flex{Alignment: layout.Middle, Spacing: layout.SpaceAround}},
+		{"hflex(around,middle)", layout.Flex{Alignment: layout.Middle, Spacing: layout.SpaceAround}},
+		{"vflex(evenly,end)", layout.Flex{Axis: layout.Vertical, Alignment: layout.End, Spacing: layout.SpaceEvenly}},
+		{"vflex(end,evenly)", layout.Flex{Axis: layout.Vertical, Alignment: layout.End, Spacing: layout.SpaceEvenly}},
+		{"vflex(between,end)", layout.Flex{Axis: layout.Vertical, Alignment: layout.End, Spacing: layout.SpaceBetween}},
+		{"vflex(end,between)", layout.Flex{Axis: layout.Vertical, Alignment: layout.End, Spacing: layout.SpaceBetween}},
+		{"hflex(center,start)", layout.Flex{Alignment: layout.Start, Spacing: layout.SpaceSides}},
+		{"hflex(start,center)", layout.Flex{Alignment: layout.Start, Spacing: layout.SpaceSides}},
+		{"hflex(end)", layout.Flex{Spacing: layout.SpaceStart}},
+		{"hflex(start,end)", layout.Flex{Alignment: layout.End}},
+		{"hflex(end,start)", layout.Flex{Alignment: layout.Start, Spacing: layout.SpaceStart}},
+		{"hflex(end,end)", layout.Flex{Alignment: layout.End, Spacing: layout.SpaceStart}},
+		{"hflex(middle,end)", layout.Flex{Alignment: layout.Middle, Spacing: layout.SpaceStart}},
+		{"hflex(end,middle)", layout.Flex{Alignment: layout.Middle, Spacing: layout.SpaceStart}},
+		{"hflex(baseline)", layout.Flex{Alignment: layout.Baseline}},
+	}
+	for _, test := range flexes {
+		d1, ops1 := layoutOps(cs, FormatF(test.style, Child("", box), Child("", box), Child("", box)))
+		d2, ops2 := layoutOps(cs, func(gtx C) D {
+			return test.flex.Layout(gtx, layout.Rigid(box), layout.Rigid(box), layout.Rigid(box))
+		})
+		if d1 != d2 || !bytes.Equal(ops1, ops2) {
+			t.Errorf("%s laid out %v, layout.Flex %v", test.style, d1, d2)
+		}
+	}
+
+	gtx := layout.Context{Ops: new(op.Ops), Constraints: layout.Constraints{Max: image.Pt(400, 100)}}
+	dims := Format(gtx, "hflex(gap(10))", Child("", box), Child("", box), Child("", box))
+	if want := image.Pt(3*48+2*10, 24); dims.Size != want {
+		t.Errorf("hflex(gap(10)) size %v, want %v", dims.Size, want)
+	}
+	dims = Format(gtx, "vflex(gap($gap))", Child("", box))
+	if want := image.Pt(48, 24); dims.Size != want {
+		t.Errorf("single child with gap size %v, want %v", dims.Size, want)
+	}
+
+	tall := func(gtx C) D {
+		return D{Size: image.Pt(48, 60)}
+	}
+	d1, ops1 := layoutOps(cs, FormatF("hflex(start,gap(4))", Child("", tall), Child("self(end);inset(2)", box), Child("f(2);self(middle)", box)))
+	d2, ops2 := layoutOps(cs, func(gtx C) D {
+		return HFlex{Gap: 4}.Layout(gtx, Rigid(tall), Rigid(Styled(box, Inset(2))).Self(layout.End), Flexed(2, box).Self(layout.Middle))
+	})
+	if d1 != d2 || !bytes.Equal(ops1, ops2) {
+		t.Errorf("HFlex with self alignment laid out %v, hflex %v", d2, d1)
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		style    string
+		min, max image.Point
+		want     layout.Constraints
+	}{
+		{"width(50%)", image.Pt(0, 0), image.Pt(400, 300), layout.Constraints{Min: image.Pt(200, 0), Max: image.Pt(200, 300)}},
+		{"height(25%);width(100)", image.Pt(0, 0), image.Pt(400, 300), layout.Constraints{Min: image.Pt(100, 75), Max: image.Pt(100, 75)}},
+		{"minsize(100,500)", image.Pt(0, 0), image.Pt(400, 300), layout.Constraints{Min: image.Pt(100, 300), Max: image.Pt(400, 300)}},
+		{"maxsize(100,0)", image.Pt(50, 50), image.Pt(400, 300), layout.Constraints{Min: image.Pt(50, 50), Max: image.Pt(100, 300)}},
+		{"maxsize(10%,10%)", image.Pt(50, 0), image.Pt(400, 300), layout.Constraints{Min: image.Pt(50, 0), Max: image.Pt(50, 30)}},
+		{"fill", image.Pt(0, 0), image.Pt(400, 300), layout.Exact(image.Pt(400, 300))},
+		{"fillx", image.Pt(0, 0), image.Pt(400, 300), layout.Constraints{Min: image.Pt(400, 0), Max: image.Pt(400, 300)}},
+		{"aspect(16:9)", image.Pt(0, 0), image.Pt(400, 300), layout.Exact(image.Pt(400, 225))},
+		{"aspect(2)", image.Pt(0, 0), image.Pt(400, 100), layout.Exact(image.Pt(200, 100))},
+		{"width(90);aspect(3:1)", image.Pt(0, 0), image.Pt(400, 300), layout.Exact(image.Pt(90, 30))},
+		{"height(60);aspect(0.5)", image.Pt(0, 0), image.Pt(400, 300), layout.Exact(image.Pt(30, 60))},
+		{"aspect(2)", image.Pt(0, 0), image.Pt(400, inf), layout.Exact(image.Pt(400, 200))},
+		{"aspect(2)", image.Pt(0, 0), image.Pt(inf, 100), layout.Exact(image.Pt(200, 100))},
+		{"aspect(2)", image.Pt(0, 0), image.Pt(inf, inf), layout.Constraints{Max: image.Pt(inf, inf)}},
+	}
+	for _, test := range tests {
+		var got layout.Constraints
+		gtx := layout.Context{Ops: new(op.Ops), Constraints: layout.Constraints{Min: test.min, Max: test.max}}
+		Widget(gtx, test.style, func(gtx C) D {
+			got = gtx.Constraints
+			return D{Size: gtx.Constraints.Min}
+		})
+		if got != test.want {
+			t.Errorf("%s: constraints %v, want %v", test.style, got, test.want)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Metric:      unit.Metric{PxPerDp: 2, PxPerSp: 3},
+		Constraints: layout.Constraints{Max: image.Pt(800, 600)},
+	}
+	tests := []struct {
+		style string
+		want  image.Point
+	}{
+		{"inset(1px,2dp,3sp,0)", image.Pt(48+1+9, 24+4)},
+		{"inset(4)", image.Pt(48+16, 24+16)},
+		{"inset(2 sp)", image.Pt(48+12, 24+12)},
+		{"size(100px,10)", image.Pt(100, 20)},
+		{"border(1px,1px,1px,1px,000000)", image.Pt(48, 24)},
+	}
+	for _, test := range tests {
+		if dims := Widget(gtx, test.style, box); dims.Size != test.want {
+			t.Errorf("%s: size %v, want %v", test.style, dims.Size, test.want)
+		}
+	}
+	if err := Validate("inset(2pt)"); err == nil {
+		t.Error("inset(2pt) is valid")
+	}
+	var v Value
+	RegisterDirective("unitcheck", Arity{{LengthParam}}, func(p []Value) Directive {
+		v = p[0]
+		return nil
+	})
+	if err := Validate("unitcheck(3sp)"); err != nil {
+		t.Fatal(err)
+	}
+	if want := unit.Sp(3); v.Length() != want {
+		t.Errorf("Length() = %v, want %v", v.Length(), want)
+	}
+}
+
+// layoutOps lays out w within cs, returning its dimensions and a copy
+// of the operations it added.
+func layoutOps(cs layout.Constraints, w layout.Widget) (D, []byte) {
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: cs}
+	dims := w(gtx)
+	return dims, append([]byte(nil), ops.Data()...)
+}
+
+// hitTest returns the index of the tag among tags that receives a press
+// at p, or -1.
+func hitTest(ops *op.Ops, tags []*int, p f32.Point) int {
+	var r router.Router
+	r.Frame(ops)
+	r.Add(pointer.Event{Type: pointer.Press, Source: pointer.Mouse, Position: p})
+	for i, tag := range tags {
+		for _, e := range r.Events(tag) {
+			if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func TestGrid(t *testing.T) {
+	var widths []int
+	var tags []*int
+	cell := func(h int) layout.Widget {
+		tag := new(int)
+		tags = append(tags, tag)
+		return func(gtx C) D {
+			widths = append(widths, gtx.Constraints.Max.X)
+			sz := image.Pt(48, h)
+			pointer.Rect(image.Rectangle{Max: sz}).Add(gtx.Ops)
+			pointer.InputOp{Tag: tag, Types: pointer.Press}.Add(gtx.Ops)
+			return D{Size: sz, Baseline: h / 3}
+		}
+	}
+	tests := []struct {
+		style    string
+		children []ChildSpec
+		size     image.Point
+		widths   []int
+		// hits are points that hit the child at the same index.
+		hits []f32.Point
+	}{
+		{
+			style:    "grid(100,1fr,auto)",
+			children: []ChildSpec{Child("", cell(24)), Child("", cell(24)), Child("", cell(24)), Child("", cell(40))},
+			size:     image.Pt(400, 64),
+			widths:   []int{400, 100, 252, 100},
+			hits:     []f32.Point{{X: 10, Y: 10}, {X: 110, Y: 10}, {X: 360, Y: 10}, {X: 10, Y: 60}},
+		},
+		{
+			style:    "grid(100,1fr,auto,gap(10,4))",
+			children: []ChildSpec{Child("", cell(24)), Child("", cell(24)), Child("", cell(24)), Child("", cell(24))},
+			size:     image.Pt(400, 52),
+			widths:   []int{400, 100, 232, 100},
+			hits:     []f32.Point{{X: 1, Y: 1}, {X: 111, Y: 1}, {X: 353, Y: 1}, {X: 1, Y: 29}},
+		},
+		{
+			style:    "grid(50,50,50)",
+			children: []ChildSpec{Child("span(2,2)", cell(60)), Child("", cell(24)), Child("cell(1,3)", cell(24)), Child("", cell(24))},
+			size:     image.Pt(150, 84),
+			widths:   []int{100, 50, 50, 50},
+			hits:     []f32.Point{{X: 1, Y: 50}, {X: 101, Y: 1}, {X: 1, Y: 61}, {X: 101, Y: 25}},
+		},
+		{
+			style:    "grid(50,50,baseline)",
+			children: []ChildSpec{Child("", cell(30)), Child("", cell(60))},
+			size:     image.Pt(100, 60),
+			widths:   []int{50, 50},
+			hits:     []f32.Point{{X: 1, Y: 21}, {X: 51, Y: 1}},
+		},
+	}
+	all := tags
+	for _, test := range tests {
+		widths, tags, all = widths[:0], all[:len(test.children)], all[len(test.children):]
+		var ops op.Ops
+		gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(400, 600)}}
+		dims := Format(gtx, test.style, test.children...)
+		if dims.Size != test.size {
+			t.Errorf("%s: size %v, want %v", test.style, dims.Size, test.size)
+		}
+		if !reflect.DeepEqual(widths, test.widths) {
+			t.Errorf("%s: widths %v, want %v", test.style, widths, test.widths)
+		}
+		for i, p := range test.hits {
+			if got := hitTest(&ops, tags, p); got != i {
+				t.Errorf("%s: press at %v hit child %d, want %d", test.style, p, got, i)
+			}
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	var tags []*int
+	chip := func(w, h int) ChildSpec {
+		tag := new(int)
+		tags = append(tags, tag)
+		return Child("", func(gtx C) D {
+			sz := image.Pt(w, h)
+			pointer.Rect(image.Rectangle{Max: sz}).Add(gtx.Ops)
+			pointer.InputOp{Tag: tag, Types: pointer.Press}.Add(gtx.Ops)
+			return D{Size: sz}
+		})
+	}
+	tests := []struct {
+		style    string
+		children []ChildSpec
+		size     image.Point
+		hits     []f32.Point
+	}{
+		{
+			style:    "wrap(gap(10,5))",
+			children: []ChildSpec{chip(40, 20), chip(40, 20), chip(40, 30), chip(100, 20)},
+			size:     image.Pt(100, 80),
+			hits:     []f32.Point{{X: 1, Y: 1}, {X: 51, Y: 1}, {X: 1, Y: 26}, {X: 1, Y: 61}},
+		},
+		{
+			style:    "wrap(end,end)",
+			children: []ChildSpec{chip(40, 20), chip(40, 10), chip(40, 10)},
+			size:     image.Pt(100, 30),
+			hits:     []f32.Point{{X: 21, Y: 1}, {X: 61, Y: 11}, {X: 61, Y: 21}},
+		},
+		{
+			style:    "wrap(between)",
+			children: []ChildSpec{chip(30, 10), chip(30, 10), chip(30, 10), chip(30, 10)},
+			size:     image.Pt(100, 20),
+			hits:     []f32.Point{{X: 1, Y: 1}, {X: 36, Y: 1}, {X: 71, Y: 1}, {X: 1, Y: 11}},
+		},
+	}
+	all := tags
+	for _, test := range tests {
+		tags, all = all[:len(test.children)], all[len(test.children):]
+		var ops op.Ops
+		gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(100, 600)}}
+		if dims := Format(gtx, test.style, test.children...); dims.Size != test.size {
+			t.Errorf("%s: size %v, want %v", test.style, dims.Size, test.size)
+		}
+		for i, p := range test.hits {
+			if got := hitTest(&ops, tags, p); got != i {
+				t.Errorf("%s: press at %v hit child %d, want %d", test.style, p, got, i)
+			}
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	var built []int
+	row := func(i int) ChildSpec {
+		return Child("", func(gtx C) D {
+			built = append(built, i)
+			return D{Size: image.Pt(gtx.Constraints.Max.X, 20)}
+		})
+	}
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Exact(image.Pt(100, 50))}
+	dims := Format(gtx, "list(key(test),gap(5))", Child("", box), Items(1000, row))
+	if dims.Size != image.Pt(100, 50) {
+		t.Errorf("size %v, want (100,50)", dims.Size)
+	}
+	// The header (24) and a gap leave room for one row and part of
+	// another.
+	if !reflect.DeepEqual(built, []int{0, 1}) {
+		t.Errorf("built rows %v, want [0 1]", built)
+	}
+
+	state := ListState("test")
+	state.Position.First = 500
+	built = built[:0]
+	ops.Reset()
+	Format(gtx, "list(key(test),gap(5))", Child("", box), Items(1000, row))
+	if len(built) == 0 || built[0] != 499 {
+		t.Errorf("built rows %v after scrolling, want from 499", built)
+	}
+	Forget("test")
+	if ListState("test") == state {
+		t.Error("Forget kept the list state")
+	}
+	Forget("test")
+
+	// Children after the Items keep their place.
+	Forget("test")
+	built = built[:0]
+	ops.Reset()
+	mark := Child("", func(gtx C) D {
+		built = append(built, -1)
+		return D{Size: image.Pt(gtx.Constraints.Max.X, 5)}
+	})
+	Format(gtx, "list(key(test))", mark, Items(2, row), mark)
+	if !reflect.DeepEqual(built, []int{-1, 0, 1, -1}) {
+		t.Errorf("built children %v, want [-1 0 1 -1]", built)
+	}
+	Forget("test")
+
+	// Touches drag the list.
+	var r router.Router
+	gtx.Queue = &r
+	for _, e := range []pointer.Event{
+		{Type: pointer.Press, Source: pointer.Touch, Position: f32.Pt(50, 40)},
+		{Type: pointer.Move, Source: pointer.Touch, Position: f32.Pt(50, 10)},
+	} {
+		ops.Reset()
+		Format(gtx, "list(key(test))", Items(1000, row))
+		r.Frame(&ops)
+		r.Add(e)
+	}
+	ops.Reset()
+	Format(gtx, "list(key(test))", Items(1000, row))
+	// Rows are 20 high.
+	if pos := ListState("test").Position; !ListDragging("test") || pos.First != 1 || pos.Offset != 10 {
+		t.Errorf("dragging %v at %+v, want dragged by 30", ListDragging("test"), pos)
+	}
+	Forget("test")
+	gtx.Queue = nil
+
+	// Other containers build all children.
+	built = built[:0]
+	ops.Reset()
+	Format(gtx, "vflex", Items(3, row))
+	if !reflect.DeepEqual(built, []int{0, 1, 2}) {
+		t.Errorf("vflex built rows %v, want [0 1 2]", built)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("List accepted a nil key")
+		}
+	}()
+	List{}.Layout(gtx, 0, nil)
+}
+
+func TestScroll(t *testing.T) {
+	var tags []*int
+	var children []ChildSpec
+	for i := 0; i < 10; i++ {
+		tag := new(int)
+		tags = append(tags, tag)
+		children = append(children, Child("", func(gtx C) D {
+			sz := image.Pt(gtx.Constraints.Max.X, 20)
+			pointer.Rect(image.Rectangle{Max: sz}).Add(gtx.Ops)
+			pointer.InputOp{Tag: tag, Types: pointer.Press}.Add(gtx.Ops)
+			return D{Size: sz}
+		}))
+	}
+	content := FormatF("vflex", children...)
+	var ops op.Ops
+	var r router.Router
+	gtx := layout.Context{Ops: &ops, Queue: &r, Constraints: layout.Constraints{Max: image.Pt(100, 50)}}
+	frame := func() D {
+		ops.Reset()
+		dims := Widget(gtx, "scroll(key(test),scrollbar)", content)
+		r.Frame(&ops)
+		return dims
+	}
+	defer Forget("test")
+
+	if dims := frame(); dims.Size != image.Pt(100, 50) {
+		t.Errorf("size %v, want (100,50)", dims.Size)
+	}
+	if got := hitTest(&ops, tags, f32.Pt(10, 45)); got != 2 {
+		t.Errorf("press hit child %d, want 2", got)
+	}
+
+	ScrollTo("test", image.Pt(0, 30))
+	frame()
+	if got := hitTest(&ops, tags, f32.Pt(10, 5)); got != 1 {
+		t.Errorf("press after ScrollTo hit child %d, want 1", got)
+	}
+
+	r.Add(pointer.Event{Type: pointer.Scroll, Source: pointer.Mouse, Position: f32.Pt(10, 10), Scroll: f32.Pt(0, 1000)})
+	frame()
+	if off := ScrollOffset("test"); off != image.Pt(0, 150) {
+		t.Errorf("offset %v after scrolling past the end, want (0,150)", off)
+	}
+	if got := hitTest(&ops, tags, f32.Pt(10, 45)); got != 9 {
+		t.Errorf("press at the end hit child %d, want 9", got)
+	}
+
+	// Mouse drags move the content once past the slop.
+	r.Add(
+		pointer.Event{Type: pointer.Press, Source: pointer.Mouse, Buttons: pointer.ButtonLeft, Position: f32.Pt(10, 10)},
+		pointer.Event{Type: pointer.Drag, Source: pointer.Mouse, Buttons: pointer.ButtonLeft, Position: f32.Pt(10, 12)},
+	)
+	frame()
+	if off := ScrollOffset("test"); off != image.Pt(0, 150) {
+		t.Errorf("offset %v after a drag within the slop, want (0,150)", off)
+	}
+	r.Add(
+		pointer.Event{Type: pointer.Drag, Source: pointer.Mouse, Buttons: pointer.ButtonLeft, Position: f32.Pt(10, 50)},
+		pointer.Event{Type: pointer.Release, Source: pointer.Mouse, Position: f32.Pt(10, 50)},
+	)
+	frame()
+	if off := ScrollOffset("test"); off != image.Pt(0, 110) {
+		t.Errorf("offset %v after dragging, want (0,110)", off)
+	}
+}
+
+func TestStates(t *testing.T) {
+	const style = "key(card);inset(4);:hover width(80);:pressed height(40);:disabled width(20)"
+	var ops op.Ops
+	var r router.Router
+	gtx := layout.Context{Ops: &ops, Queue: &r, Constraints: layout.Constraints{Max: image.Pt(400, 400)}}
+	frame := func() D {
+		ops.Reset()
+		dims := Widget(gtx, style, box)
+		r.Frame(&ops)
+		return dims
+	}
+	defer Forget("card")
+
+	if dims := frame(); dims.Size != image.Pt(56, 32) {
+		t.Errorf("size %v, want (56,32)", dims.Size)
+	}
+	r.Add(pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(10, 10)})
+	if dims := frame(); dims.Size != image.Pt(88, 32) || States("card") != Hovered {
+		t.Errorf("hovered: size %v, states %v", dims.Size, States("card"))
+	}
+	r.Add(pointer.Event{Type: pointer.Press, Source: pointer.Mouse, Position: f32.Pt(10, 10)})
+	if dims := frame(); dims.Size != image.Pt(88, 48) || States("card") != Hovered|Pressed {
+		t.Errorf("pressed: size %v, states %v", dims.Size, States("card"))
+	}
+	r.Add(
+		pointer.Event{Type: pointer.Release, Source: pointer.Mouse, Position: f32.Pt(10, 10)},
+		pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(200, 10)},
+	)
+	if dims := frame(); dims.Size != image.Pt(56, 32) || States("card") != 0 {
+		t.Errorf("left: size %v, states %v", dims.Size, States("card"))
+	}
+	SetDisabled("card", true)
+	r.Add(pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(10, 10)})
+	if dims := frame(); dims.Size != image.Pt(28, 32) || States("card") != Disabled {
+		t.Errorf("disabled: size %v, states %v", dims.Size, States("card"))
+	}
+
+	// The handlers of a disabled widget receive no events.
+	tag := new(int)
+	var presses int
+	handler := func(gtx C) D {
+		presses += len(gtx.Events(tag))
+		pointer.Rect(image.Rectangle{Max: image.Pt(48, 24)}).Add(gtx.Ops)
+		pointer.InputOp{Tag: tag, Types: pointer.Press}.Add(gtx.Ops)
+		return box(gtx)
+	}
+	for i := 0; i < 2; i++ {
+		ops.Reset()
+		Widget(gtx, style, handler)
+		r.Frame(&ops)
+		r.Add(pointer.Event{Type: pointer.Press, Source: pointer.Mouse, Position: f32.Pt(10, 10)})
+	}
+	if presses != 0 {
+		t.Errorf("a handler within a disabled widget received %d events", presses)
+	}
+
+	// A disabled context stays disabled within an interactive widget.
+	Widget(gtx.Disabled(), style, func(gtx C) D {
+		if gtx.Queue != nil {
+			t.Error("key enabled a disabled widget")
+		}
+		return box(gtx)
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Interactive accepted a nil key")
+		}
+	}()
+	Interactive(nil, Hovered)
+}
+
+func TestClick(t *testing.T) {
+	var ops op.Ops
+	var r router.Router
+	gtx := layout.Context{Ops: &ops, Queue: &r, Constraints: layout.Constraints{Max: image.Pt(400, 400)}}
+	defer Forget("ok")
+	frame := func(events ...event.Event) []ClickKind {
+		r.Add(events...)
+		var kinds []ClickKind
+		for _, e := range Clicked(gtx, "ok") {
+			kinds = append(kinds, e.Kind)
+		}
+		ops.Reset()
+		Widget(gtx, "click(ok);inset(4)", box)
+		r.Frame(&ops)
+		return kinds
+	}
+	press := func(x, y float32, t time.Duration) pointer.Event {
+		return pointer.Event{Type: pointer.Press, Source: pointer.Mouse, Position: f32.Pt(x, y), Time: t}
+	}
+	release := func(x, y float32, t time.Duration) pointer.Event {
+		return pointer.Event{Type: pointer.Release, Source: pointer.Mouse, Position: f32.Pt(x, y), Time: t}
+	}
+	frame()
+
+	tests := []struct {
+		name   string
+		now    time.Duration
+		events []event.Event
+		kinds  []ClickKind
+	}{
+		{"click", 0, []event.Event{press(10, 10, 0), release(10, 10, 10*time.Millisecond)}, []ClickKind{Click}},
+		{"double click", 0, []event.Event{press(10, 10, 100*time.Millisecond), release(10, 10, 110*time.Millisecond)}, []ClickKind{Click, DoubleClick}},
+		{"release outside", 0, []event.Event{press(10, 10, time.Second), release(100, 10, time.Second)}, nil},
+		{"press", time.Second, []event.Event{press(10, 10, 2*time.Second)}, nil},
+		{"long press", 2 * time.Second, nil, []ClickKind{LongPress}},
+		{"release after long press", 2 * time.Second, []event.Event{release(10, 10, 3*time.Second)}, nil},
+		{"disabled", 3 * time.Second, []event.Event{press(10, 10, 4*time.Second), release(10, 10, 4*time.Second)}, nil},
+		{"disabled press", 3 * time.Second, []event.Event{press(10, 10, 5*time.Second)}, nil},
+		{"enabled", 3 * time.Second, []event.Event{release(10, 10, 5*time.Second)}, nil},
+		{"click after enabled", 3 * time.Second, []event.Event{press(10, 10, 6*time.Second), release(10, 10, 6*time.Second)}, []ClickKind{Click}},
+	}
+	for _, test := range tests {
+		SetDisabled("ok", strings.HasPrefix(test.name, "disabled"))
+		gtx.Now = time.Unix(0, 0).Add(test.now)
+		if kinds := frame(test.events...); !reflect.DeepEqual(kinds, test.kinds) {
+			t.Errorf("%s: clicks %v, want %v", test.name, kinds, test.kinds)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Clickable(nil) did not panic")
+		}
+	}()
+	Clickable(nil)
+}
+
+func TestDebug(t *testing.T) {
+	SetDebug(true)
+	defer SetDebug(false)
+	var ops op.Ops
+	var r router.Router
+	gtx := layout.Context{Ops: &ops, Queue: &r, Constraints: layout.Constraints{Max: image.Pt(400, 400)}}
+	var clicks []ClickEvent
+	frame := func() D {
+		ops.Reset()
+		clicks = append(clicks, Clicked(gtx, "dbg")...)
+		dims := Format(gtx, "vflex;inset(8)",
+			Child("click(dbg);inset(4)", box),
+			Child(";border(1,1,1,1,000000)", box),
+		)
+		r.Frame(&ops)
+		return dims
+	}
+	defer Forget("dbg")
+	info := func() string {
+		debugState.Lock()
+		defer debugState.Unlock()
+		return debugState.info
+	}
+
+	if dims := frame(); dims.Size != image.Pt(72, 72) {
+		t.Errorf("size %v, want (72,72)", dims.Size)
+	}
+	r.Add(pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(20, 20)})
+	frame()
+	if got := info(); !strings.HasPrefix(got, "click(dbg);inset(4)\nsize 56x32") {
+		t.Errorf("hovered info %q", got)
+	}
+	r.Add(pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(4, 60)})
+	frame()
+	if got := info(); !strings.HasPrefix(got, "vflex;inset(8)\nsize 72x72") {
+		t.Errorf("hovered info %q", got)
+	}
+
+	// Clicks pin a box and still reach the widgets.
+	r.Add(
+		pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(20, 20)},
+		pointer.Event{Type: pointer.Press, Source: pointer.Mouse, Position: f32.Pt(20, 20)},
+		pointer.Event{Type: pointer.Release, Source: pointer.Mouse, Position: f32.Pt(20, 20)},
+	)
+	frame()
+	frame()
+	if len(clicks) != 1 {
+		t.Errorf("clicks %v, want 1", clicks)
+	}
+	r.Add(pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(20, 50)})
+	frame()
+	if got := info(); !strings.HasPrefix(got, "click(dbg);inset(4)") {
+		t.Errorf("pinned info %q", got)
+	}
+	r.Add(
+		pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(20, 20)},
+		pointer.Event{Type: pointer.Press, Source: pointer.Mouse, Position: f32.Pt(20, 20)},
+		pointer.Event{Type: pointer.Release, Source: pointer.Mouse, Position: f32.Pt(20, 20)},
+		pointer.Event{Type: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(20, 50)},
+	)
+	frame()
+	if got := info(); !strings.HasPrefix(got, ";border(1,1,1,1,000000)\nsize 48x24") {
+		t.Errorf("unpinned info %q", got)
+	}
+
+	Widget(gtx.Disabled(), "inset(4)", func(gtx C) D {
+		if gtx.Queue != nil {
+			t.Error("the inspector enabled a disabled widget")
+		}
+		return box(gtx)
+	})
+}
@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/paint"
+)
+
+// maxStops is the largest number of colors in a gradient, each with a
+// stop.
+const maxStops = 8
+
+// LinearGradient is the typed form of lgradient(angle,color1,color2,...).
+func LinearGradient(angle float32, cols ...color.RGBA) Style {
+	return styleOf(gradientS{gradient: linearGradient(angle, colorsOf(cols))})
+}
+
+// RadialGradient is the typed form of rgradient(color1,color2,...).
+func RadialGradient(cols ...color.RGBA) Style {
+	return styleOf(gradientS{gradient: radialGradient(colorsOf(cols))})
+}
+
+// RoundedLinearGradient is the typed form of
+// lgradient(angle,color1,color2,...) in a style with a radius.
+func RoundedLinearGradient(r Corners, angle float32, cols ...color.RGBA) Style {
+	return styleOf(gradientS{linearGradient(angle, colorsOf(cols)), r.corners()})
+}
+
+// RoundedRadialGradient is the typed form of rgradient(color1,color2,...)
+// in a style with a radius.
+func RoundedRadialGradient(r Corners, cols ...color.RGBA) Style {
+	return styleOf(gradientS{radialGradient(colorsOf(cols)), r.corners()})
+}
+
+// Stop is a color of a gradient at an offset between 0 and 1 along it.
+type Stop struct {
+	Offset float32
+	Color  color.RGBA
+}
+
+// LinearGradientStops is the typed form of
+// lgradient(angle,color1,color2,...,stop1,stop2,...).
+func LinearGradientStops(angle float32, stops ...Stop) Style {
+	cols, offs := stopsOf(stops)
+	return styleOf(gradientS{gradient: gradient{angle: angle, cols: cols, stops: offs}})
+}
+
+// RadialGradientStops is the typed form of
+// rgradient(color1,color2,...,stop1,stop2,...).
+func RadialGradientStops(stops ...Stop) Style {
+	cols, offs := stopsOf(stops)
+	return styleOf(gradientS{gradient: gradient{radial: true, cols: cols, stops: offs}})
+}
+
+// FillLinearStops is like FillLinear, with the colors at stops.
+func FillLinearStops(angle float32, stops ...Stop) layout.Widget {
+	cols, offs := stopsOf(stops)
+	return fillGradient(gradient{angle: angle, cols: cols, stops: offs})
+}
+
+// FillRadialStops is like FillRadial, with the colors at stops.
+func FillRadialStops(stops ...Stop) layout.Widget {
+	cols, offs := stopsOf(stops)
+	return fillGradient(gradient{radial: true, cols: cols, stops: offs})
+}
+
+// FillLinear is like Fill, with a linear gradient.
+func FillLinear(angle float32, cols ...color.RGBA) layout.Widget {
+	return fillGradient(linearGradient(angle, colorsOf(cols)))
+}
+
+// FillRadial is like Fill, with a radial gradient.
+func FillRadial(cols ...color.RGBA) layout.Widget {
+	return fillGradient(radialGradient(colorsOf(cols)))
+}
+
+func fillGradient(g gradient) layout.Widget {
+	return func(gtx C) D {
+		d := gtx.Constraints.Min
+		g.paint(gtx, d)
+		return layout.Dimensions{Size: d}
+	}
+}
+
+func stopsOf(stops []Stop) ([]colorRef, []float32) {
+	if len(stops) > maxStops {
+		stops = stops[:maxStops]
+	}
+	cols := make([]colorRef, len(stops))
+	offs := make([]float32, len(stops))
+	for i, s := range stops {
+		cols[i], offs[i] = colorOf(s.Color), s.Offset
+	}
+	return cols, offs
+}
+
+func colorsOf(cols []color.RGBA) []colorRef {
+	if len(cols) > maxStops {
+		cols = cols[:maxStops]
+	}
+	refs := make([]colorRef, len(cols))
+	for i, c := range cols {
+		refs[i] = colorOf(c)
+	}
+	return refs
+}
+
+// gradient is a linear or radial gradient.
+type gradient struct {
+	radial bool
+	// angle is the direction of a linear gradient in degrees, clockwise
+	// from left to right.
+	angle float32
+	cols  []colorRef
+	// stops are the offsets of cols along the gradient, or nil if they
+	// are evenly spaced.
+	stops []float32
+}
+
+func linearGradient(angle float32, cols []colorRef) gradient {
+	return gradient{angle: angle, cols: cols}
+}
+
+func radialGradient(cols []colorRef) gradient {
+	return gradient{radial: true, cols: cols}
+}
+
+type gradientKey struct {
+	size   image.Point
+	radial bool
+	angle  float32
+	n      int
+	cols   [maxStops]color.RGBA
+	stops  [maxStops]float32
+}
+
+var gradients = newImageCache(64)
+
+// paint paints the gradient over a rectangle of size sz.
+func (g gradient) paint(gtx C, sz image.Point) {
+	if sz.X <= 0 || sz.Y <= 0 || len(g.cols) == 0 {
+		return
+	}
+	k := gradientKey{size: sz, radial: g.radial, angle: g.angle, n: len(g.cols)}
+	for i, c := range g.cols {
+		k.cols[i] = Fade(gtx, c.rgba())
+		switch {
+		case g.stops != nil:
+			k.stops[i] = g.stops[i]
+		case k.n > 1:
+			k.stops[i] = float32(i) / float32(k.n-1)
+		}
+	}
+	img := gradients.get(imageKey{gradient: k}, func() image.Image {
+		return renderGradient(k)
+	})
+	defer op.Push(gtx.Ops).Pop()
+	img.Add(gtx.Ops)
+	paint.PaintOp{Rect: f32.Rectangle{Max: layout.FPt(sz)}}.Add(gtx.Ops)
+}
+
+// renderGradient rasterizes a gradient. Linear gradients span the
+// rectangle along their direction, so that the first and last colors
+// touch opposite corners; radial gradients span from the center to the
+// farthest corner.
+func renderGradient(k gradientKey) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: k.size})
+	w, h := float64(k.size.X), float64(k.size.Y)
+	a := float64(k.angle) * math.Pi / 180
+	dx, dy := math.Cos(a), math.Sin(a)
+	span := math.Abs(w*dx) + math.Abs(h*dy)
+	if k.radial {
+		span = math.Hypot(w, h) / 2
+	}
+	for y := 0; y < k.size.Y; y++ {
+		for x := 0; x < k.size.X; x++ {
+			px, py := float64(x)+.5-w/2, float64(y)+.5-h/2
+			var t float64
+			if k.radial {
+				t = math.Hypot(px, py) / span
+			} else {
+				t = (px*dx+py*dy)/span + .5
+			}
+			c := k.at(t)
+			i := img.PixOffset(x, y)
+			img.Pix[i+0], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+	}
+	return img
+}
+
+// at returns the color at position t along the gradient, with the
+// first and last colors extending past their stops.
+func (k *gradientKey) at(t float64) color.RGBA {
+	if t <= float64(k.stops[0]) {
+		return k.cols[0]
+	}
+	for i := 1; i < k.n; i++ {
+		s0, s1 := float64(k.stops[i-1]), float64(k.stops[i])
+		if t < s1 {
+			return mix(k.cols[i-1], k.cols[i], (t-s0)/(s1-s0))
+		}
+	}
+	return k.cols[k.n-1]
+}
+
+// mix interpolates between the premultiplied colors c1 and c2, which
+// keeps a transparent end from darkening the other.
+func mix(c1, c2 color.RGBA, t float64) color.RGBA {
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t + .5)
+	}
+	return color.RGBA{R: lerp(c1.R, c2.R), G: lerp(c1.G, c2.G), B: lerp(c1.B, c2.B), A: lerp(c1.A, c2.A)}
+}
+
+// gradientS paints a gradient behind a widget, like backgroundS.
+type gradientS struct {
+	gradient
+	r corners
+}
+
+func (s gradientS) withRadius(r corners) (directive, bool) {
+	if !s.r.isZero() {
+		return s, false
+	}
+	s.r = r
+	return s, true
+}
+
+func (s gradientS) Layout(gtx C, w layout.Widget) D {
+	call, dims := behind(gtx, w)
+	stack := op.Push(gtx.Ops)
+	if !s.r.isZero() {
+		fsz := layout.FPt(dims.Size)
+		clipRRect(gtx.Ops, f32.Rectangle{Max: fsz}, s.r.px(gtx, fsz))
+	}
+	s.paint(gtx, dims.Size)
+	stack.Pop()
+	call.Add(gtx.Ops)
+	return dims
+}
+
+// gradient compiles lgradient(angle,color1,color2,...,stop1,stop2,...)
+// and rgradient(color1,color2,...,stop1,stop2,...), where the stops are
+// optional percentages, one per color.
+func (c *compiler) gradient(s section, radial bool) directive {
+	lead := 1
+	if radial {
+		lead = 0
+	}
+	if len(s.params) < lead {
+		c.arity(s, lead+2)
+		return nil
+	}
+	params := s.params[lead:]
+	nstops := 0
+	for nstops < len(params) && isStop(params[len(params)-1-nstops]) {
+		nstops++
+	}
+	n := len(params) - nstops
+	if n < 2 || n > maxStops || nstops != 0 && nstops != n {
+		c.errorf(s.off, s.name, "want 2 to %d colors, and as many stops if any, got %d colors and %d stops", maxStops, n, nstops)
+		return nil
+	}
+	g := gradient{radial: radial, cols: make([]colorRef, n)}
+	if !radial {
+		g.angle = c.float(s, 0)
+	}
+	for i := range g.cols {
+		g.cols[i] = c.color(s, lead+i)
+	}
+	if nstops > 0 {
+		g.stops = make([]float32, n)
+		for i := range g.stops {
+			p := params[n+i]
+			v, err := strconv.ParseFloat(strings.TrimSuffix(p.s, "%"), 32)
+			v /= 100
+			if err != nil || v < 0 || v > 1 || i > 0 && float32(v) < g.stops[i-1] {
+				c.errorf(p.off, s.name, "invalid stop %q", p.s)
+				return nil
+			}
+			g.stops[i] = float32(v)
+		}
+	}
+	return gradientS{gradient: g}
+}
+
+// isStop reports whether p is a gradient stop, a percentage.
+func isStop(p param) bool {
+	return !p.call && strings.HasSuffix(p.s, "%")
+}
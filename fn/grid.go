@@ -0,0 +1,415 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"strconv"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// GridColumn is the width of a Grid column.
+type GridColumn struct {
+	kind trackKind
+	size length
+	fr   float32
+}
+
+type trackKind uint8
+
+const (
+	fixedTrack trackKind = iota
+	frTrack
+	autoTrack
+)
+
+// FixedColumn is a column of a fixed width in dp, the typed form of a
+// grid column such as 120.
+func FixedColumn(width float32) GridColumn {
+	return GridColumn{kind: fixedTrack, size: dp(width)}
+}
+
+// FrColumn is a column sharing the width left by the other columns by
+// weight, the typed form of a grid column such as 1fr.
+func FrColumn(weight float32) GridColumn {
+	return GridColumn{kind: frTrack, fr: weight}
+}
+
+// AutoColumn is a column as wide as its widest child, the typed form of
+// the auto grid column.
+func AutoColumn() GridColumn {
+	return GridColumn{kind: autoTrack}
+}
+
+// Grid is the typed form of grid(columns...,alignment,gap(col,row)).
+type Grid struct {
+	Columns []GridColumn
+	// Alignment aligns the children of a row vertically.
+	Alignment layout.Alignment
+	// ColumnGap and RowGap are the spaces between columns and rows in
+	// dp.
+	ColumnGap, RowGap float32
+}
+
+func (g Grid) Layout(gtx C, items ...Item) D {
+	return gridC{g.Columns, g.Alignment, dp(g.ColumnGap), dp(g.RowGap)}.Layout(gtx, items)
+}
+
+// Span makes a grid child span several columns and rows, the typed form
+// of the span(cols,rows) prefix.
+func (it Item) Span(cols, rows int) Item {
+	it.ColSpan, it.RowSpan = cols, rows
+	return it
+}
+
+// Cell places a grid child at a column and row counted from 1, the typed
+// form of the cell(col,row) prefix.
+func (it Item) Cell(col, row int) Item {
+	it.Col, it.Row = col, row
+	return it
+}
+
+// gridC lays out children in rows of columns. Children without a cell
+// are placed in the next free cells, row by row.
+type gridC struct {
+	cols           []GridColumn
+	align          layout.Alignment
+	colGap, rowGap length
+}
+
+type gridChild struct {
+	col, row   int
+	cols, rows int
+	call       op.CallOp
+	dims       D
+}
+
+func (g gridC) Layout(gtx C, items []Item) D {
+	ncols := len(g.cols)
+	if ncols == 0 || len(items) == 0 {
+		return D{Size: gtx.Constraints.Min}
+	}
+	var childBuf [16]gridChild
+	children := g.place(items, childBuf[:0])
+	nrows := 0
+	for _, ch := range children {
+		if r := ch.row + ch.rows; r > nrows {
+			nrows = r
+		}
+	}
+	colGap, rowGap := g.colGap.px(gtx), g.rowGap.px(gtx)
+	cs := gtx.Constraints
+
+	// Size fixed and auto columns, then share the rest among fr columns.
+	var widthBuf [8]int
+	var laidBuf [16]bool
+	widths := ints(widthBuf[:0], ncols)
+	laid := bools(laidBuf[:0], len(children))
+	var totalFr float32
+	for i, c := range g.cols {
+		switch c.kind {
+		case fixedTrack:
+			widths[i] = c.size.px(gtx)
+		case frTrack:
+			totalFr += c.fr
+		}
+	}
+	for i, ch := range children {
+		if ch.cols != 1 || g.cols[ch.col].kind != autoTrack {
+			continue
+		}
+		g.layoutChild(gtx, items[i].Widget, &children[i], cs.Max.X)
+		laid[i] = true
+		if w := children[i].dims.Size.X; w > widths[ch.col] {
+			widths[ch.col] = w
+		}
+	}
+	if totalFr > 0 {
+		rest := cs.Max.X - colGap*(ncols-1)
+		for i, c := range g.cols {
+			if c.kind != frTrack {
+				rest -= widths[i]
+			}
+		}
+		if rest < 0 {
+			rest = 0
+		}
+		var fraction float32
+		for i, c := range g.cols {
+			if c.kind != frTrack {
+				continue
+			}
+			w := float32(rest) * c.fr / totalFr
+			widths[i] = int(w + fraction + .5)
+			fraction = w - float32(widths[i])
+		}
+	}
+	for i := range children {
+		if laid[i] {
+			continue
+		}
+		ch := &children[i]
+		g.layoutChild(gtx, items[i].Widget, ch, span(widths, ch.col, ch.cols, colGap))
+	}
+
+	// Size rows to their tallest child, aligning baselines if asked.
+	var heightBuf, ascentBuf [16]int
+	heights := ints(heightBuf[:0], nrows)
+	ascents := ints(ascentBuf[:0], nrows)
+	if g.align == layout.Baseline {
+		for _, ch := range children {
+			if a := ch.dims.Size.Y - ch.dims.Baseline; ch.rows == 1 && a > ascents[ch.row] {
+				ascents[ch.row] = a
+			}
+		}
+	}
+	for _, ch := range children {
+		if ch.rows != 1 {
+			continue
+		}
+		h := ch.dims.Size.Y
+		if g.align == layout.Baseline {
+			h += ascents[ch.row] - (ch.dims.Size.Y - ch.dims.Baseline)
+		}
+		if h > heights[ch.row] {
+			heights[ch.row] = h
+		}
+	}
+	for _, ch := range children {
+		if ch.rows == 1 {
+			continue
+		}
+		last := ch.row + ch.rows - 1
+		if h := span(heights, ch.row, ch.rows, rowGap); ch.dims.Size.Y > h {
+			heights[last] += ch.dims.Size.Y - h
+		}
+	}
+
+	for _, ch := range children {
+		x := span(widths, 0, ch.col, colGap)
+		y := span(heights, 0, ch.row, rowGap)
+		if ch.col > 0 {
+			x += colGap
+		}
+		if ch.row > 0 {
+			y += rowGap
+		}
+		h := span(heights, ch.row, ch.rows, rowGap)
+		switch g.align {
+		case layout.Middle:
+			y += (h - ch.dims.Size.Y) / 2
+		case layout.End:
+			y += h - ch.dims.Size.Y
+		case layout.Baseline:
+			if ch.rows == 1 {
+				y += ascents[ch.row] - (ch.dims.Size.Y - ch.dims.Baseline)
+			}
+		}
+		stack := op.Push(gtx.Ops)
+		op.Offset(layout.FPt(image.Pt(x, y))).Add(gtx.Ops)
+		ch.call.Add(gtx.Ops)
+		stack.Pop()
+	}
+	sz := image.Pt(span(widths, 0, ncols, colGap), span(heights, 0, nrows, rowGap))
+	dims := D{Size: cs.Constrain(sz)}
+	if nrows > 0 && g.align == layout.Baseline {
+		dims.Baseline = dims.Size.Y - ascents[0]
+	}
+	return dims
+}
+
+// layoutChild records w laid out in a cell of the given width.
+func (g gridC) layoutChild(gtx C, w layout.Widget, ch *gridChild, width int) {
+	m := op.Record(gtx.Ops)
+	gtx.Constraints.Min = image.Point{}
+	gtx.Constraints.Max.X = width
+	ch.dims = w(gtx)
+	ch.call = m.Stop()
+}
+
+// place assigns the cells of the children, in buf if it is large
+// enough.
+func (g gridC) place(items []Item, buf []gridChild) []gridChild {
+	ncols := len(g.cols)
+	children := buf[:0]
+	if len(items) > cap(buf) {
+		children = make([]gridChild, 0, len(items))
+	}
+	children = children[:len(items)]
+	for i := range children {
+		children[i] = gridChild{}
+	}
+	// used marks the taken cells, row by row.
+	var usedBuf [64]bool
+	used := usedBuf[:0]
+	free := func(col, row, cols, rows int) bool {
+		for r := row; r < row+rows && r*ncols < len(used); r++ {
+			for c := col; c < col+cols; c++ {
+				if used[r*ncols+c] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	// Explicit cells are placed first, so that the others flow around
+	// them.
+	for pass := 0; pass < 2; pass++ {
+		next := 0
+		for i, it := range items {
+			explicit := it.Col > 0 || it.Row > 0
+			if explicit != (pass == 0) {
+				continue
+			}
+			ch := &children[i]
+			ch.cols, ch.rows = it.ColSpan, it.RowSpan
+			if ch.cols < 1 {
+				ch.cols = 1
+			}
+			if ch.cols > ncols {
+				ch.cols = ncols
+			}
+			if ch.rows < 1 {
+				ch.rows = 1
+			}
+			if explicit {
+				ch.col, ch.row = it.Col-1, it.Row-1
+				if ch.col < 0 {
+					ch.col = 0
+				}
+				if ch.row < 0 {
+					ch.row = 0
+				}
+				if ch.col+ch.cols > ncols {
+					ch.col = ncols - ch.cols
+				}
+			} else {
+				for ; ; next++ {
+					col, row := next%ncols, next/ncols
+					if col+ch.cols <= ncols && free(col, row, ch.cols, ch.rows) {
+						ch.col, ch.row = col, row
+						break
+					}
+				}
+			}
+			for len(used) < (ch.row+ch.rows)*ncols {
+				used = append(used, false)
+			}
+			for r := ch.row; r < ch.row+ch.rows; r++ {
+				for c := ch.col; c < ch.col+ch.cols; c++ {
+					used[r*ncols+c] = true
+				}
+			}
+		}
+	}
+	return children
+}
+
+// ints returns n zeroed ints, in buf if it is large enough.
+func ints(buf []int, n int) []int {
+	if n > cap(buf) {
+		return make([]int, n)
+	}
+	buf = buf[:n]
+	for i := range buf {
+		buf[i] = 0
+	}
+	return buf
+}
+
+// bools returns n false bools, in buf if it is large enough.
+func bools(buf []bool, n int) []bool {
+	if n > cap(buf) {
+		return make([]bool, n)
+	}
+	buf = buf[:n]
+	for i := range buf {
+		buf[i] = false
+	}
+	return buf
+}
+
+// span returns the size of n tracks from i and the gaps between them.
+func span(sizes []int, i, n, gap int) int {
+	s := 0
+	for j := i; j < i+n && j < len(sizes); j++ {
+		s += sizes[j]
+	}
+	if n > 1 {
+		s += gap * (n - 1)
+	}
+	return s
+}
+
+// grid compiles the parameters of grid: its columns, an alignment and
+// gap(n) or gap(col,row), in any order.
+func (c *compiler) grid(s section) Container {
+	var g gridC
+	for i, p := range s.params {
+		switch {
+		case p.call && p.s == "gap":
+			gs := section{name: p.s, params: p.args, off: p.off}
+			if c.arity(gs, 1, 2) {
+				g.colGap = c.length(gs, 0)
+				g.rowGap = g.colGap
+				if len(p.args) == 2 {
+					g.rowGap = c.length(gs, 1)
+				}
+			}
+		case p.call:
+			c.errorf(p.off, s.name, "invalid parameter %s(...)", p.s)
+		case p.s == "auto":
+			g.cols = append(g.cols, AutoColumn())
+		case strings.HasSuffix(p.s, "fr"):
+			w, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(p.s, "fr")), 32)
+			if err != nil || w < 0 {
+				c.errorf(p.off, s.name, "invalid column %q", p.s)
+			}
+			g.cols = append(g.cols, FrColumn(float32(w)))
+		default:
+			if a, ok := alignmentFor(p.s); ok {
+				g.align = a
+				continue
+			}
+			g.cols = append(g.cols, GridColumn{kind: fixedTrack, size: c.length(s, i)})
+		}
+	}
+	if len(g.cols) == 0 && c.err == nil {
+		c.errorf(s.off, s.name, "missing columns")
+	}
+	return g
+}
+
+// span compiles the span(cols,rows) prefix.
+func (c *compiler) span(s section) (cols, rows int) {
+	if !c.arity(s, 1, 2) {
+		return 1, 1
+	}
+	cols, rows = c.count(s, 0), 1
+	if len(s.params) == 2 {
+		rows = c.count(s, 1)
+	}
+	return cols, rows
+}
+
+// cell compiles the cell(col,row) prefix.
+func (c *compiler) cell(s section) (col, row int) {
+	if !c.arity(s, 2) {
+		return 0, 0
+	}
+	return c.count(s, 0), c.count(s, 1)
+}
+
+// count parses a positive integer parameter.
+func (c *compiler) count(s section, i int) int {
+	p := s.params[i]
+	n, err := strconv.Atoi(p.s)
+	if err != nil || n < 1 {
+		c.errorf(p.off, s.name, "invalid count %q", p.s)
+		return 1
+	}
+	return n
+}
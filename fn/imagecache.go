@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"sync"
+
+	"gioui.org/op/paint"
+)
+
+// imageCache holds images rendered on the CPU, such as shadow masks and
+// gradients, so that they are rendered and uploaded once per size
+// rather than every frame.
+type imageCache struct {
+	mu  sync.Mutex
+	max int
+	m   map[imageKey]cachedImage
+}
+
+// imageKey identifies a cached image. Only one of its fields is set; a
+// concrete key spares each lookup the boxing of an interface key.
+type imageKey struct {
+	shadow   shadowKey
+	gradient gradientKey
+	img      image.Image
+}
+
+// cachedImage is an image along with its ImageOp.
+type cachedImage struct {
+	src image.Image
+	op  paint.ImageOp
+}
+
+func newImageCache(max int) *imageCache {
+	return &imageCache{max: max, m: make(map[imageKey]cachedImage)}
+}
+
+// get returns the image for key, calling render if it is not cached.
+// The cache is emptied when it is full.
+func (c *imageCache) get(key imageKey, render func() image.Image) paint.ImageOp {
+	return c.lookup(key, render).op
+}
+
+// lookup is like get, but also returns the rendered image.
+func (c *imageCache) lookup(key imageKey, render func() image.Image) cachedImage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if img, ok := c.m[key]; ok {
+		return img
+	}
+	if len(c.m) >= c.max {
+		c.m = make(map[imageKey]cachedImage)
+	}
+	src := render()
+	img := cachedImage{src: src, op: paint.NewImageOp(src)}
+	c.m[key] = img
+	return img
+}
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// State is a set of interaction states of a widget.
+type State uint8
+
+const (
+	Hovered State = 1 << iota
+	Pressed
+	Focused
+	Disabled
+)
+
+var stateNames = map[string]State{
+	"hover":    Hovered,
+	"pressed":  Pressed,
+	"focused":  Focused,
+	"disabled": Disabled,
+}
+
+// Interactive tracks the states of the widget it styles under key, the
+// typed form of key(name), where key may be any comparable value other
+// than nil. Widgets sharing a key share their states.
+func Interactive(key interface{}, states State) Style {
+	if key == nil {
+		panic("fn: Interactive with a nil key")
+	}
+	return styleOf(interactS{key: key, states: states})
+}
+
+// When applies s while the nearest enclosing Interactive widget is in
+// any of states, the typed form of a :state section.
+func When(states State, s Style) Style {
+	return styleOf(stateD{states, styleD{s}})
+}
+
+// States returns the current states of the widget with key.
+func States(key interface{}) State {
+	return trackerFor(key).current()
+}
+
+// SetDisabled disables or enables the widget with key. A disabled
+// widget is in the Disabled state only. The change shows from the next
+// frame.
+func SetDisabled(key interface{}, disabled bool) {
+	t := trackerFor(key)
+	t.disabled = disabled
+	t.states = 0
+}
+
+// tracker follows the pointer and focus events of an interactive
+// widget.
+type tracker struct {
+	states   State
+	disabled bool
+	// focus requests the focus at the next layout, after a press.
+	focus bool
+}
+
+func trackerFor(key interface{}) *tracker {
+	return stateFor(interactState, key, func() interface{} { return new(tracker) }).(*tracker)
+}
+
+func (t *tracker) current() State {
+	if t.disabled {
+		return Disabled
+	}
+	return t.states
+}
+
+func (t *tracker) update(gtx C, states State) {
+	for _, e := range gtx.Events(t) {
+		switch e := e.(type) {
+		case pointer.Event:
+			switch e.Type {
+			case pointer.Enter:
+				t.states |= Hovered
+			case pointer.Leave:
+				t.states &^= Hovered
+			case pointer.Press:
+				t.states |= Pressed
+				t.focus = states&Focused != 0 && t.states&Focused == 0
+			case pointer.Release, pointer.Cancel:
+				t.states &^= Pressed
+				// Touches do not hover.
+				if e.Source == pointer.Touch {
+					t.states &^= Hovered
+				}
+			}
+		case key.FocusEvent:
+			if e.Focus {
+				t.states |= Focused
+			} else {
+				t.states &^= Focused
+			}
+		}
+	}
+}
+
+// interactS tracks the states of its widget by key, and passes them to
+// the state sections within.
+type interactS struct {
+	key    interface{}
+	states State
+}
+
+func (s interactS) Layout(gtx C, w layout.Widget) D {
+	t := trackerFor(s.key)
+	if !t.disabled {
+		t.update(gtx, s.states)
+	}
+	q := queueOf(gtx)
+	q.states = t.current()
+
+	m := op.Record(gtx.Ops)
+	if t.disabled {
+		// The handlers within a disabled widget receive no events.
+		gtx = gtx.Disabled()
+		q.Queue = nil
+	}
+	dims := withStyle(gtx, q, w)
+	call := m.Stop()
+	if t.disabled {
+		call.Add(gtx.Ops)
+		return dims
+	}
+	// The widget is nested in the area, so that its own handlers still
+	// receive events.
+	defer op.Push(gtx.Ops).Pop()
+	var types pointer.Type
+	if s.states&Hovered != 0 {
+		types |= pointer.Enter | pointer.Leave
+	}
+	if s.states&(Hovered|Pressed|Focused) != 0 {
+		types |= pointer.Press | pointer.Release | pointer.Cancel
+	}
+	if types != 0 {
+		pointer.Rect(image.Rectangle{Max: dims.Size}).Add(gtx.Ops)
+		pointer.InputOp{Tag: t, Types: types}.Add(gtx.Ops)
+	}
+	if s.states&Focused != 0 {
+		key.InputOp{Tag: t, Focus: t.focus}.Add(gtx.Ops)
+		t.focus = false
+	}
+	call.Add(gtx.Ops)
+	return dims
+}
+
+// statesOf returns the states of the interactive widget enclosing gtx.
+func statesOf(gtx C) State {
+	return queueOf(gtx).states
+}
+
+// stateD applies d only in the given states.
+type stateD struct {
+	states State
+	d      directive
+}
+
+func (s stateD) Layout(gtx C, w layout.Widget) D {
+	if statesOf(gtx)&s.states == 0 {
+		return w(gtx)
+	}
+	return s.d.Layout(gtx, w)
+}
+
+func (s stateD) withRadius(r corners) (directive, bool) {
+	d, ok := s.d.(rounder)
+	if !ok {
+		return s, false
+	}
+	s.d, ok = d.withRadius(r)
+	return s, ok
+}
+
+// interactive completes the interactS of chain with the states of its
+// state sections. It reports false if the chain has state sections but
+// no key(name) to track them under.
+func interactive(chain []directive) ([]directive, bool) {
+	var states State
+	at := -1
+	for i, d := range chain {
+		switch d := d.(type) {
+		case stateD:
+			states |= d.states
+		case interactS:
+			if at < 0 {
+				at = i
+			}
+		}
+	}
+	if at < 0 {
+		return chain, states == 0
+	}
+	s := chain[at].(interactS)
+	s.states = states
+	chain[at] = s
+	return chain, true
+}
+
+// interactive compiles key(name), which marks where the interactive
+// area of a widget starts.
+func (c *compiler) interactive(s section) directive {
+	if name, ok := c.name(s); ok {
+		return interactS{key: name}
+	}
+	return nil
+}
+
+// states compiles the state sections of sec, such as :hover bkground(f0f0f0).
+func (c *compiler) states(chain []directive, sec section) []directive {
+	st, ok := stateNames[sec.state]
+	if !ok {
+		c.errorf(sec.stateOff, ":"+sec.state, "unknown state")
+		return chain
+	}
+	if c.stateSec == nil {
+		c.stateSec = &section{name: ":" + sec.state, off: sec.stateOff}
+	}
+	n := len(chain)
+	sec.state = ""
+	chain = c.chain(chain, sec)
+	for i := n; i < len(chain); i++ {
+		chain[i] = stateD{st, chain[i]}
+	}
+	return chain
+}
@@ -0,0 +1,341 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+
+	"gioui.org/gesture"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// Items returns a child that stands for n children built by child on
+// demand. A list container builds only the children it shows, in their
+// place among the other children of the Format call; other containers
+// build them all.
+func Items(n int, child func(i int) ChildSpec) ChildSpec {
+	return ChildSpec{n: n, build: child}
+}
+
+// List is the typed form of list(axis,alignment,key(k),gap(n),scrollbar),
+// where Key may be any comparable value.
+type List struct {
+	Axis      layout.Axis
+	Alignment layout.Alignment
+	// Key identifies the scroll state of the list, which is kept by fn.
+	// Lists with the same key share their state. Key must not be nil.
+	Key interface{}
+	// Gap is the space between children in dp.
+	Gap       float32
+	Scrollbar bool
+}
+
+func (l List) Layout(gtx C, n int, child func(gtx C, i int) D) D {
+	if l.Key == nil {
+		panic("fn: List with a nil key")
+	}
+	return listC{l.Axis, l.Alignment, l.Key, dp(l.Gap), l.Scrollbar}.layoutLazy(gtx, n, child)
+}
+
+// ListState returns the layout.List fn keeps for key, to read or set
+// its Position or ScrollToEnd.
+func ListState(key interface{}) *layout.List {
+	return &listStateFor(key).list
+}
+
+// ListDragging reports whether the list with key is being dragged.
+func ListDragging(key interface{}) bool {
+	return listStateFor(key).scroll.State() == gesture.StateDragging
+}
+
+// listS is the state of a list container. Only the exported fields of
+// list are used: fn lays the list out itself, since layout.List boxes
+// its Context for the scroll gesture, allocating every frame.
+type listS struct {
+	list   layout.List
+	scroll gesture.Scroll
+	bar    scrollbar
+	// children are the children laid out by the last frame.
+	children []listChild
+	// first and count track the children laid out by the last frame.
+	first, count int
+}
+
+// listChild is a laid out child of a list.
+type listChild struct {
+	size image.Point
+	call op.CallOp
+}
+
+func listStateFor(key interface{}) *listS {
+	return stateFor(listState, key, func() interface{} { return new(listS) }).(*listS)
+}
+
+// lazyContainer is implemented by containers that build the children of
+// Items on demand.
+type lazyContainer interface {
+	layoutLazy(gtx C, n int, child func(gtx C, i int) D) D
+}
+
+// listC is a virtualized layout.List whose state is kept by key.
+type listC struct {
+	axis      layout.Axis
+	align     layout.Alignment
+	key       interface{}
+	gap       length
+	scrollbar bool
+}
+
+func (c listC) Layout(gtx C, items []Item) D {
+	return c.layoutLazy(gtx, len(items), func(gtx C, i int) D {
+		return items[i].Widget(gtx)
+	})
+}
+
+func (c listC) layoutLazy(gtx C, n int, child func(gtx C, i int) D) D {
+	s := listStateFor(c.key)
+	s.list.Axis, s.list.Alignment = c.axis, c.align
+	gap := c.gap.px(gtx)
+	s.first, s.count = n, 0
+	dims := s.layout(gtx, c.axis, n, func(gtx C, i int) D {
+		if i < s.first {
+			s.first = i
+		}
+		s.count++
+		if i == 0 || gap == 0 {
+			return child(gtx, i)
+		}
+		off := image.Pt(gap, 0)
+		if c.axis == layout.Vertical {
+			off = image.Pt(0, gap)
+		}
+		stack := op.Push(gtx.Ops)
+		op.Offset(layout.FPt(off)).Add(gtx.Ops)
+		dims := child(gtx, i)
+		stack.Pop()
+		dims.Size = dims.Size.Add(off)
+		return dims
+	})
+	if c.scrollbar && n > 0 {
+		start := float32(s.first) / float32(n)
+		end := float32(s.first+s.count) / float32(n)
+		if to, ok := s.bar.layout(gtx, c.axis, dims.Size, start, end); ok {
+			s.list.Position.First = int(to*float32(n) + .5)
+			s.list.Position.Offset = 0
+			s.list.Position.BeforeEnd = true
+			op.InvalidateOp{}.Add(gtx.Ops)
+		}
+	}
+	return dims
+}
+
+// layout lays out the n children of the list from its Position, as
+// layout.List does: children are built forwards until they fill the
+// list, or backwards while the Position is before the first.
+func (s *listS) layout(gtx C, axis layout.Axis, n int, child func(gtx C, i int) D) D {
+	l := &s.list
+	f := flexC{axis: axis}
+	var delta int
+	if gtx.Queue != nil {
+		delta = s.scroll.Scroll(gtx.Metric, gtx.Queue, gtx.Now, gesture.Axis(axis))
+	}
+	l.Position.Offset += delta
+	toEnd := func() bool { return l.ScrollToEnd && !l.Position.BeforeEnd }
+	if toEnd() || l.Position.First > n {
+		l.Position.Offset = 0
+		l.Position.First = n
+	}
+
+	cs := gtx.Constraints
+	mainMin, mainMax := f.main(cs.Min), f.main(cs.Max)
+	crossMin, crossMax := f.cross(cs.Min), f.cross(cs.Max)
+	const inf = 1e6
+	ccs := f.constraints(0, inf, crossMin, crossMax)
+	m := op.Record(gtx.Ops)
+	s.children = s.children[:0]
+	size := 0
+	// next reports the index of the next child to lay out, and whether
+	// it goes before the others.
+	next := func() (int, bool, bool) {
+		last := l.Position.First + len(s.children)
+		if size-l.Position.Offset < mainMax && last == n {
+			l.Position.Offset = size - mainMax
+		}
+		if l.Position.Offset < 0 && l.Position.First == 0 {
+			l.Position.Offset = 0
+		}
+		switch {
+		case len(s.children) == n:
+			return 0, false, false
+		case size-l.Position.Offset < mainMax:
+			return last, false, true
+		case l.Position.Offset < 0:
+			return l.Position.First - 1, true, true
+		}
+		return 0, false, false
+	}
+	for {
+		i, backward, ok := next()
+		// The scroll offset is applied after scrolling to the end.
+		if !ok && toEnd() && delta < 0 {
+			l.Position.BeforeEnd = true
+			l.Position.Offset += delta
+			i, backward, ok = next()
+		}
+		if !ok {
+			break
+		}
+		cm := op.Record(gtx.Ops)
+		gtx.Constraints = ccs
+		dims := child(gtx, i)
+		ch := listChild{dims.Size, cm.Stop()}
+		sz := f.main(ch.size)
+		size += sz
+		if !backward {
+			s.children = append(s.children, ch)
+			continue
+		}
+		s.children = append(s.children, listChild{})
+		copy(s.children[1:], s.children)
+		s.children[0] = ch
+		l.Position.First--
+		l.Position.Offset += sz
+	}
+
+	// Skip the children scrolled out of view.
+	children := s.children
+	for len(children) > 0 {
+		sz := f.main(children[0].size)
+		if l.Position.Offset <= sz {
+			break
+		}
+		l.Position.First++
+		l.Position.Offset -= sz
+		children = children[1:]
+	}
+	size = -l.Position.Offset
+	var maxCross int
+	for i, ch := range children {
+		if c := f.cross(ch.size); c > maxCross {
+			maxCross = c
+		}
+		size += f.main(ch.size)
+		if size >= mainMax {
+			children = children[:i+1]
+			break
+		}
+	}
+	pos := -l.Position.Offset
+	// ScrollToEnd lists are end aligned.
+	if space := mainMax - size; l.ScrollToEnd && space > 0 {
+		pos += space
+	}
+	for _, ch := range children {
+		var cross int
+		switch l.Alignment {
+		case layout.End:
+			cross = maxCross - f.cross(ch.size)
+		case layout.Middle:
+			cross = (maxCross - f.cross(ch.size)) / 2
+		}
+		sz := f.main(ch.size)
+		max := sz + pos
+		if max > mainMax {
+			max = mainMax
+		}
+		min := pos
+		if min < 0 {
+			min = 0
+		}
+		r := image.Rectangle{Min: f.point(min, -inf), Max: f.point(max, inf)}
+		stack := op.Push(gtx.Ops)
+		clip.Rect(r).Add(gtx.Ops)
+		op.Offset(layout.FPt(f.point(pos, cross))).Add(gtx.Ops)
+		ch.call.Add(gtx.Ops)
+		stack.Pop()
+		pos += sz
+	}
+	atStart := l.Position.First == 0 && l.Position.Offset <= 0
+	atEnd := l.Position.First+len(children) == n && mainMax >= pos
+	if atStart && delta < 0 || atEnd && delta > 0 {
+		s.scroll.Stop()
+	}
+	l.Position.BeforeEnd = !atEnd
+	if pos < mainMin {
+		pos = mainMin
+	}
+	if pos > mainMax {
+		pos = mainMax
+	}
+	sz := f.point(pos, maxCross)
+	call := m.Stop()
+	defer op.Push(gtx.Ops).Pop()
+	pointer.Rect(image.Rectangle{Max: sz}).Add(gtx.Ops)
+	s.scroll.Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	return D{Size: sz}
+}
+
+// list compiles the parameters of list: an axis, an alignment, key(k),
+// gap(n) and scrollbar, in any order. The key is required, since the
+// Program is shared by every list with the same style.
+func (c *compiler) list(s section) Container {
+	l := listC{axis: layout.Vertical}
+	for _, p := range s.params {
+		switch {
+		case p.call && p.s == "gap":
+			g := section{name: p.s, params: p.args, off: p.off}
+			if c.arity(g, 1) {
+				l.gap = c.length(g, 0)
+			}
+		case p.call && p.s == "key":
+			if k, ok := c.key(s, p); ok {
+				l.key = k
+			}
+		case p.call:
+			c.errorf(p.off, s.name, "invalid parameter %s(...)", p.s)
+		case p.s == "v":
+			l.axis = layout.Vertical
+		case p.s == "h":
+			l.axis = layout.Horizontal
+		case p.s == "scrollbar":
+			l.scrollbar = true
+		default:
+			if a, ok := alignmentFor(p.s); ok && a != layout.Baseline {
+				l.align = a
+				continue
+			}
+			c.errorf(p.off, s.name, "invalid parameter %q", p.s)
+		}
+	}
+	if l.key == nil && c.err == nil {
+		c.errorf(s.off, s.name, "missing key(name)")
+	}
+	return l
+}
+
+// name compiles the single name parameter of a section such as
+// key(name) or click(name).
+func (c *compiler) name(s section) (string, bool) {
+	if !c.arity(s, 1) {
+		return "", false
+	}
+	p := s.params[0]
+	if p.call || !isName(p.s) {
+		c.errorf(p.off, s.name, "invalid key")
+		return "", false
+	}
+	return p.s, true
+}
+
+// key compiles the key(name) parameter p of s.
+func (c *compiler) key(s section, p param) (interface{}, bool) {
+	if len(p.args) != 1 || p.args[0].call || !isName(p.args[0].s) {
+		c.errorf(p.off, s.name, "invalid key")
+		return nil, false
+	}
+	return p.args[0].s, true
+}
@@ -0,0 +1,382 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+// section is a single parsed `name(param,...)` section of a style.
+type section struct {
+	name   string
+	params []param
+	// off is the byte offset of the section in the style.
+	off int
+	// state is the state of a :state section, such as hover, and
+	// stateOff its byte offset.
+	state    string
+	stateOff int
+}
+
+// param is a single section parameter. A parameter may itself be a
+// call such as rgba(0,0,0,0.5), in which case s is the called name.
+type param struct {
+	s    string
+	off  int
+	call bool
+	args []param
+}
+
+// compiler parses a style string, recording the first error it
+// encounters. Parsing continues after an error so that the remaining
+// sections still produce something to lay out.
+type compiler struct {
+	style string
+	pos   int
+	err   *SyntaxError
+
+	// expanding is the stack of classes being expanded.
+	expanding []string
+	// defs are class definitions being validated by Define or
+	// LoadStylesheet, which take precedence over the defined classes.
+	defs map[string]string
+	// styles are named styles being validated by LoadStylesheet, which
+	// take precedence over the loaded ones.
+	styles map[string]string
+	// refs are the theme references of the style, which resolve when it
+	// is laid out.
+	refs []themeRef
+	// stateSec is the first state section of the style, or the class
+	// that has it, and transSec the same for transitions.
+	stateSec, transSec *section
+}
+
+// themeRef is a $name theme reference, for Validate to check.
+type themeRef struct {
+	name  string
+	color bool
+	// off and sec locate the reference, or the class using it.
+	off int
+	sec string
+	// class is the class the reference is in, if any.
+	class string
+}
+
+func (c *compiler) errorf(off int, name, format string, args ...interface{}) {
+	if c.err != nil {
+		return
+	}
+	c.err = &SyntaxError{Style: c.style, Offset: off, Name: name, Msg: fmt.Sprintf(format, args...)}
+}
+
+// sections splits the style into its top level ';' separated sections,
+// skipping empty ones. lead reports whether the first returned section
+// is the leading section of the style, the slot reserved for a
+// container or child prefix.
+func (c *compiler) sections() (secs []section, lead bool) {
+	for i := 0; ; i++ {
+		if sec, ok := c.section(); ok {
+			if i == 0 {
+				lead = true
+			}
+			secs = append(secs, sec)
+		}
+		if c.pos >= len(c.style) {
+			return secs, lead
+		}
+		// Skip the ';'.
+		c.pos++
+	}
+}
+
+// section parses a section up to the next top level ';'.
+func (c *compiler) section() (section, bool) {
+	name, off := c.atom()
+	sec := section{name: name, off: off}
+	if strings.HasPrefix(name, ":") {
+		sec.state, sec.stateOff = name[1:], off
+		if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+			sec.state = name[1:i]
+			sec.name = strings.TrimSpace(name[i:])
+			sec.off = off + strings.Index(name[i:], sec.name) + i
+		} else {
+			sec.name = ""
+			c.errorf(off, name, "missing directive")
+		}
+	}
+	if c.peek() == '(' {
+		sec.params = c.args(name)
+	}
+	c.space()
+	if b := c.peek(); b != ';' && b != 0 {
+		start := c.pos
+		for c.pos < len(c.style) && c.style[c.pos] != ';' {
+			c.pos++
+		}
+		c.errorf(start, name, "unexpected %q", c.style[start:c.pos])
+	}
+	return sec, name != "" || sec.params != nil
+}
+
+// args parses a parenthesized, comma separated parameter list.
+func (c *compiler) args(name string) []param {
+	// Skip the '('.
+	c.pos++
+	var params []param
+	c.space()
+	if c.peek() == ')' {
+		c.pos++
+		return params
+	}
+	for {
+		s, off := c.atom()
+		p := param{s: s, off: off}
+		if c.peek() == '(' {
+			p.call = true
+			p.args = c.args(s)
+		}
+		params = append(params, p)
+		c.space()
+		switch c.peek() {
+		case ',':
+			c.pos++
+		case ')':
+			c.pos++
+			return params
+		default:
+			c.errorf(c.pos, name, "missing )")
+			return params
+		}
+	}
+}
+
+// atom scans a run of characters up to the next delimiter and returns
+// it with surrounding space removed, along with its offset.
+func (c *compiler) atom() (string, int) {
+	c.space()
+	start := c.pos
+	for c.pos < len(c.style) && !strings.ContainsRune("(),;", rune(c.style[c.pos])) {
+		c.pos++
+	}
+	return strings.TrimSpace(c.style[start:c.pos]), start
+}
+
+func (c *compiler) space() {
+	for c.pos < len(c.style) && (c.style[c.pos] == ' ' || c.style[c.pos] == '\t' || c.style[c.pos] == '\n') {
+		c.pos++
+	}
+}
+
+// peek returns the byte at the cursor, or 0 at the end of the style.
+func (c *compiler) peek() byte {
+	if c.pos >= len(c.style) {
+		return 0
+	}
+	return c.style[c.pos]
+}
+
+// arity reports whether s has one of the accepted parameter counts.
+func (c *compiler) arity(s section, n ...int) bool {
+	for _, k := range n {
+		if len(s.params) == k {
+			return true
+		}
+	}
+	if c.err == nil {
+		c.err = &SyntaxError{
+			Style:  c.style,
+			Offset: s.off,
+			Name:   s.name,
+			Arity:  n,
+			Msg:    fmt.Sprintf("want %s parameters, got %d", arityString(n), len(s.params)),
+		}
+	}
+	return false
+}
+
+func arityString(n []int) string {
+	var b strings.Builder
+	for i, k := range n {
+		if i > 0 {
+			if i == len(n)-1 {
+				b.WriteString(" or ")
+			} else {
+				b.WriteString(", ")
+			}
+		}
+		b.WriteString(strconv.Itoa(k))
+	}
+	return b.String()
+}
+
+func (c *compiler) float(s section, i int) float32 {
+	p := s.params[i]
+	f, err := strconv.ParseFloat(p.s, 32)
+	if err != nil {
+		c.errorf(p.off, s.name, "invalid number %q", p.s)
+	}
+	return float32(f)
+}
+
+var units = []struct {
+	suffix string
+	unit   unit.Unit
+}{
+	{"dp", unit.UnitDp},
+	{"sp", unit.UnitSp},
+	{"px", unit.UnitPx},
+}
+
+// length parses a numeric parameter with an optional dp, sp or px
+// suffix, or a $name theme reference. Numbers without a suffix are in
+// dp.
+func (c *compiler) length(s section, i int) length {
+	if ref, ok := c.ref(s, i); ok {
+		c.refs = append(c.refs, themeRef{name: ref, off: s.params[i].off, sec: s.name})
+		return length{ref: ref}
+	}
+	p := s.params[i]
+	l := length{u: unit.UnitDp}
+	for _, u := range units {
+		if strings.HasSuffix(p.s, u.suffix) && !p.call {
+			p.s = strings.TrimSpace(strings.TrimSuffix(p.s, u.suffix))
+			l.u = u.unit
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(p.s, 32)
+	if err != nil {
+		c.errorf(p.off, s.name, "invalid length %q", c.text(s.params[i]))
+	}
+	l.v = float32(f)
+	return l
+}
+
+// color parses a color parameter, or a $name theme reference.
+func (c *compiler) color(s section, i int) colorRef {
+	if ref, ok := c.ref(s, i); ok {
+		c.refs = append(c.refs, themeRef{name: ref, color: true, off: s.params[i].off, sec: s.name})
+		return colorRef{ref: ref}
+	}
+	p := s.params[i]
+	col, ok := parseColor(p)
+	if !ok {
+		c.errorf(p.off, s.name, "invalid color %q", c.text(p))
+	}
+	return colorOf(col)
+}
+
+// ref reports whether parameter i is a $name theme reference.
+func (c *compiler) ref(s section, i int) (string, bool) {
+	p := s.params[i]
+	if p.call || !strings.HasPrefix(p.s, "$") {
+		return "", false
+	}
+	name := p.s[1:]
+	if !isName(name) {
+		c.errorf(p.off, s.name, "invalid theme reference %q", p.s)
+	}
+	return name, true
+}
+
+// isName reports whether s is a valid theme or class name.
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// text returns the source text of p, including any call arguments.
+func (c *compiler) text(p param) string {
+	end := p.off + len(p.s)
+	if p.call {
+		if end = strings.IndexByte(c.style[end:], ')'); end < 0 {
+			end = len(c.style)
+		} else {
+			end += p.off + len(p.s) + 1
+		}
+	}
+	return c.style[p.off:end]
+}
+
+// flex compiles the parameters of hflex and vflex.
+func (c *compiler) flex(axis layout.Axis, s section) flexC {
+	a, sp, gaps := c.flow(s, 1)
+	return flexC{axis, sp, a, gaps[0]}
+}
+
+// flow compiles the parameters of the flex and wrap containers: an
+// alignment, a spacing and a gap with up to ngaps lengths, in any order.
+// Since start and end are both spacings and alignments, they are the
+// alignment if another spacing is given, and otherwise the first of
+// them is the spacing. A single gap length applies to every gap.
+func (c *compiler) flow(s section, ngaps int) (a layout.Alignment, sp layout.Spacing, gaps [2]length) {
+	spaced := false
+	// either are the parameters that are both a spacing and an alignment,
+	// resolved once every spacing is known.
+	var either []param
+	for _, p := range s.params {
+		if p.call {
+			if p.s != "gap" {
+				c.errorf(p.off, s.name, "invalid parameter %s(...)", p.s)
+				continue
+			}
+			g := section{name: p.s, params: p.args, off: p.off}
+			counts := []int{1, 2}[:ngaps]
+			if c.arity(g, counts...) {
+				gaps[0] = c.length(g, 0)
+				gaps[1] = gaps[0]
+				if len(p.args) == 2 {
+					gaps[1] = c.length(g, 1)
+				}
+			}
+			continue
+		}
+		spc, isSpacing := spacingFor(p.s)
+		al, isAlignment := alignmentFor(p.s)
+		switch {
+		case isSpacing && isAlignment:
+			either = append(either, p)
+		case isSpacing && !spaced:
+			sp = spc
+			spaced = true
+		case isAlignment:
+			a = al
+		default:
+			c.errorf(p.off, s.name, "invalid alignment or spacing %q", p.s)
+		}
+	}
+	for _, p := range either {
+		if !spaced {
+			sp, _ = spacingFor(p.s)
+			spaced = true
+			continue
+		}
+		a, _ = alignmentFor(p.s)
+	}
+	return a, sp, gaps
+}
+
+func (c *compiler) stack(s section) layout.Stack {
+	st := layout.Stack{}
+	for _, p := range s.params {
+		if d, ok := directionFor(p.s); ok {
+			st.Alignment = d
+			continue
+		}
+		c.errorf(p.off, s.name, "invalid direction %q", p.s)
+	}
+	return st
+}
@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"sync"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+// Param describes the kind of a directive or container parameter.
+type Param struct {
+	kind paramKind
+	enum []string
+}
+
+type paramKind uint8
+
+const (
+	floatParam paramKind = iota
+	lengthParam
+	colorParam
+	enumParam
+	// extentParam is a length or a percentage of the maximum
+	// constraint, and ratioParam a ratio such as 16:9. They are used by
+	// the built in sizing directives only.
+	extentParam
+	ratioParam
+)
+
+var (
+	// FloatParam is a plain number, such as a weight.
+	FloatParam = Param{kind: floatParam}
+	// LengthParam is a number in dp, sp or px, such as 8, 12sp or 1px,
+	// or a $name theme spacing or radius.
+	LengthParam = Param{kind: lengthParam}
+	// ColorParam is any color accepted by bkground, or a $name theme color.
+	ColorParam = Param{kind: colorParam}
+)
+
+// EnumParam is one of the given keywords.
+func EnumParam(values ...string) Param {
+	return Param{kind: enumParam, enum: values}
+}
+
+// Arity lists the parameter signatures a directive or container
+// accepts. A section is parsed with the signature that has as many
+// parameters as the section. A nil Arity accepts no parameters.
+type Arity [][]Param
+
+// Value is a parsed parameter. Theme references are resolved by the
+// accessors, so call them while laying out rather than in a factory to
+// follow theme changes.
+type Value struct {
+	kind paramKind
+	f    float32
+	l    length
+	c    colorRef
+	s    string
+	e    extent
+}
+
+// Float returns the value of a FloatParam.
+func (v Value) Float() float32 {
+	return v.f
+}
+
+// Length returns the value of a LengthParam.
+func (v Value) Length() unit.Value {
+	return v.l.value()
+}
+
+// Color returns the value of a ColorParam.
+func (v Value) Color() color.RGBA {
+	return v.c.rgba()
+}
+
+// String returns the keyword of an EnumParam.
+func (v Value) String() string {
+	return v.s
+}
+
+// Item is a styled child of a container.
+type Item struct {
+	Widget layout.Widget
+	// Flexed and Weight are set by the f(weight) child prefix.
+	Flexed bool
+	Weight float32
+	// Expanded is set by the e child prefix.
+	Expanded bool
+	// Aligned and Align are set by the self(alignment) child prefix,
+	// overriding the cross axis alignment of a flex.
+	Aligned bool
+	Align   layout.Alignment
+	// Col and Row are set by the cell(col,row) child prefix, counting
+	// from 1, and ColSpan and RowSpan by span(cols,rows). They place the
+	// children of a grid.
+	Col, Row         int
+	ColSpan, RowSpan int
+}
+
+// Directive lays out a widget styled by a section of a style string.
+// Directives are created when the style is compiled and reused for
+// every layout, so Layout should not allocate.
+type Directive interface {
+	Layout(gtx C, w layout.Widget) D
+}
+
+// DirectiveFunc adapts a function to a Directive.
+type DirectiveFunc func(gtx C, w layout.Widget) D
+
+func (f DirectiveFunc) Layout(gtx C, w layout.Widget) D {
+	return f(gtx, w)
+}
+
+// Container lays out the children of a Format call.
+type Container interface {
+	Layout(gtx C, items []Item) D
+}
+
+type directiveEntry struct {
+	arity   Arity
+	factory func(v []Value) directive
+	// parse, if set, compiles sections that do not fit an Arity.
+	parse func(c *compiler, s section) directive
+}
+
+type containerEntry struct {
+	arity   Arity
+	factory func(v []Value) Container
+	// parse, if set, compiles sections that do not fit an Arity.
+	parse func(c *compiler, s section) Container
+}
+
+var registry = struct {
+	sync.RWMutex
+	directives map[string]directiveEntry
+	containers map[string]containerEntry
+}{
+	directives: make(map[string]directiveEntry),
+	containers: make(map[string]containerEntry),
+}
+
+// RegisterDirective makes a styling directive available to style
+// strings. The parameters of each use are parsed according to arity and
+// passed to factory when the style is compiled; a nil Directive leaves
+// the widget unstyled. RegisterDirective panics if name is invalid or
+// already registered.
+func RegisterDirective(name string, arity Arity, factory func(params []Value) Directive) {
+	registerDirective(name, arity, func(v []Value) directive {
+		return factory(v)
+	})
+}
+
+// RegisterContainer makes a container available as the first section
+// of Format styles, alongside hflex, vflex and stack. It panics if name
+// is invalid or already registered.
+func RegisterContainer(name string, arity Arity, factory func(params []Value) Container) {
+	registerContainer(name, containerEntry{arity: arity, factory: factory})
+}
+
+func registerDirective(name string, arity Arity, factory func(v []Value) directive) {
+	registerDirectiveEntry(name, directiveEntry{arity: arity, factory: factory})
+}
+
+func registerDirectiveEntry(name string, e directiveEntry) {
+	registry.Lock()
+	defer registry.Unlock()
+	checkName(name)
+	registry.directives[name] = e
+	resetPrograms()
+}
+
+func registerContainer(name string, e containerEntry) {
+	registry.Lock()
+	defer registry.Unlock()
+	checkName(name)
+	registry.containers[name] = e
+	resetPrograms()
+}
+
+func checkName(name string) {
+	if !isName(name) {
+		panic(fmt.Sprintf("fn: invalid name %q", name))
+	}
+	_, dup1 := registry.directives[name]
+	_, dup2 := registry.containers[name]
+	if dup1 || dup2 {
+		panic(fmt.Sprintf("fn: %s registered twice", name))
+	}
+}
+
+// resetPrograms discards every cached Program, so that styles are
+// compiled again against new directives and classes.
+func resetPrograms() {
+	programs.Lock()
+	programs.m, programs.old = make(map[string]*Program), nil
+	programs.Unlock()
+}
+
+func lookupDirective(name string) (directiveEntry, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	e, ok := registry.directives[name]
+	return e, ok
+}
+
+func lookupContainer(name string) (containerEntry, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	e, ok := registry.containers[name]
+	return e, ok
+}
+
+// values parses the parameters of s according to arity.
+func (c *compiler) values(s section, arity Arity) ([]Value, bool) {
+	if len(arity) == 0 {
+		arity = Arity{nil}
+	}
+	var sig []Param
+	found := false
+	counts := make([]int, len(arity))
+	for i, a := range arity {
+		counts[i] = len(a)
+		if len(a) == len(s.params) {
+			sig, found = a, true
+		}
+	}
+	if !found {
+		c.arity(s, counts...)
+		return nil, false
+	}
+	vals := make([]Value, len(sig))
+	for i, p := range sig {
+		vals[i] = c.value(s, i, p)
+	}
+	return vals, true
+}
+
+func (c *compiler) value(s section, i int, p Param) Value {
+	v := Value{kind: p.kind}
+	switch p.kind {
+	case floatParam:
+		v.f = c.float(s, i)
+	case lengthParam:
+		v.l = c.length(s, i)
+	case colorParam:
+		v.c = c.color(s, i)
+	case extentParam:
+		v.e = c.extent(s, i)
+	case ratioParam:
+		v.f = c.ratio(s, i)
+	case enumParam:
+		q := s.params[i]
+		v.s = q.s
+		found := false
+		for _, e := range p.enum {
+			if e == q.s {
+				found = true
+				break
+			}
+		}
+		if !found || q.call {
+			c.errorf(q.off, s.name, "invalid %q, want one of %s", q.s, strings.Join(p.enum, ", "))
+		}
+	}
+	return v
+}
+
+// styleD adapts a Style to a directive, for typed APIs that take one.
+type styleD struct {
+	style Style
+}
+
+func (d styleD) Layout(gtx C, w layout.Widget) D {
+	return d.style(w)(gtx)
+}
@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// Corners holds per corner radii in dp.
+type Corners struct {
+	NW, NE, SE, SW float32
+}
+
+// Radius is the typed form of radius(r).
+func Radius(r float32) Style {
+	return styleOf(radiusS{corners{dp(r), dp(r), dp(r), dp(r)}})
+}
+
+// Radius4 is the typed form of radius(nw,ne,se,sw).
+func Radius4(nw, ne, se, sw float32) Style {
+	return styleOf(radiusS{corners{dp(nw), dp(ne), dp(se), dp(sw)}})
+}
+
+// RoundedBorder is the typed form of border(left,top,right,bottom,color)
+// in a style with a radius.
+func RoundedBorder(left, top, right, bottom float32, col color.RGBA, r Corners) Style {
+	return styleOf(borderS{dp(left), dp(top), dp(right), dp(bottom), colorOf(col), r.corners()})
+}
+
+// RoundedBackground is the typed form of bkground(color) in a style with
+// a radius.
+func RoundedBackground(col color.RGBA, r Corners) Style {
+	return styleOf(backgroundS{colorOf(col), r.corners()})
+}
+
+func (c Corners) corners() corners {
+	return corners{dp(c.NW), dp(c.NE), dp(c.SE), dp(c.SW)}
+}
+
+// corners are the radii of a rounded rectangle.
+type corners struct {
+	nw, ne, se, sw length
+}
+
+func (c corners) isZero() bool {
+	return c == corners{}
+}
+
+// px returns the radii in pixels, limited to half the shorter side of
+// a rectangle of size sz.
+func (c corners) px(gtx C, sz f32.Point) rradii {
+	lim := sz.X
+	if sz.Y < lim {
+		lim = sz.Y
+	}
+	lim /= 2
+	px := func(l length) float32 {
+		v := float32(l.px(gtx))
+		if v > lim {
+			v = lim
+		}
+		if v < 0 {
+			v = 0
+		}
+		return v
+	}
+	return rradii{px(c.nw), px(c.ne), px(c.se), px(c.sw)}
+}
+
+// rradii are corner radii in pixels.
+type rradii struct {
+	nw, ne, se, sw float32
+}
+
+// shrink returns the radii of an outline inset by the given widths.
+func (r rradii) shrink(left, top, right, bottom float32) rradii {
+	sub := func(v, a, b float32) float32 {
+		if b > a {
+			a = b
+		}
+		if v -= a; v < 0 {
+			v = 0
+		}
+		return v
+	}
+	return rradii{sub(r.nw, left, top), sub(r.ne, right, top), sub(r.se, right, bottom), sub(r.sw, left, bottom)}
+}
+
+// radiusS clips a widget to a rounded rectangle at its natural size.
+type radiusS struct {
+	r corners
+}
+
+func (s radiusS) Layout(gtx C, w layout.Widget) D {
+	m := op.Record(gtx.Ops)
+	dims := w(gtx)
+	call := m.Stop()
+
+	defer op.Push(gtx.Ops).Pop()
+	sz := layout.FPt(dims.Size)
+	clipRRect(gtx.Ops, f32.Rectangle{Max: sz}, s.r.px(gtx, sz))
+	call.Add(gtx.Ops)
+	return dims
+}
+
+func clipRRect(ops *op.Ops, r f32.Rectangle, rr rradii) {
+	clip.RRect{Rect: r, NW: rr.nw, NE: rr.ne, SE: rr.se, SW: rr.sw}.Add(ops)
+}
+
+// clipRing clips to the area between two rounded rectangles, where
+// inner lies within outer.
+func clipRing(ops *op.Ops, outer f32.Rectangle, or rradii, inner f32.Rectangle, ir rradii) {
+	var p clip.Path
+	p.Begin(ops)
+	p.Move(outer.Min)
+	end := roundRect(&p, outer.Size(), or)
+	p.Move(inner.Min.Sub(outer.Min.Add(end)))
+	roundRectRev(&p, inner.Size(), ir)
+	p.End().Add(ops)
+}
+
+// roundRect adds the clockwise outline of a rounded rectangle to a path,
+// starting from the pen. It returns the pen position relative to the
+// start.
+func roundRect(p *clip.Path, size f32.Point, r rradii) f32.Point {
+	// https://pomax.github.io/bezierinfo/#circles_cubic.
+	w, h := size.X, size.Y
+	se, sw, nw, ne := r.se, r.sw, r.nw, r.ne
+	const c = 0.55228475 // 4*(sqrt(2)-1)/3
+	p.Move(f32.Point{X: w, Y: h - se})
+	p.Cube(f32.Point{X: 0, Y: se * c}, f32.Point{X: -se + se*c, Y: se}, f32.Point{X: -se, Y: se}) // SE
+	p.Line(f32.Point{X: sw - w + se, Y: 0})
+	p.Cube(f32.Point{X: -sw * c, Y: 0}, f32.Point{X: -sw, Y: -sw + sw*c}, f32.Point{X: -sw, Y: -sw}) // SW
+	p.Line(f32.Point{X: 0, Y: nw - h + sw})
+	p.Cube(f32.Point{X: 0, Y: -nw * c}, f32.Point{X: nw - nw*c, Y: -nw}, f32.Point{X: nw, Y: -nw}) // NW
+	p.Line(f32.Point{X: w - ne - nw, Y: 0})
+	p.Cube(f32.Point{X: ne * c, Y: 0}, f32.Point{X: ne, Y: ne - ne*c}, f32.Point{X: ne, Y: ne}) // NE
+	return f32.Point{X: w, Y: ne}
+}
+
+// roundRectRev is like roundRect but counter-clockwise, so that it cuts
+// a hole in an enclosing outline.
+func roundRectRev(p *clip.Path, size f32.Point, r rradii) {
+	w, h := size.X, size.Y
+	se, sw, nw, ne := r.se, r.sw, r.nw, r.ne
+	const c = 0.55228475
+	p.Move(f32.Point{X: 0, Y: h - sw})
+	p.Cube(f32.Point{X: 0, Y: sw * c}, f32.Point{X: sw - sw*c, Y: sw}, f32.Point{X: sw, Y: sw}) // SW
+	p.Line(f32.Point{X: -se + w - sw, Y: 0})
+	p.Cube(f32.Point{X: se * c, Y: 0}, f32.Point{X: se, Y: -se + se*c}, f32.Point{X: se, Y: -se}) // SE
+	p.Line(f32.Point{X: 0, Y: ne - h + se})
+	p.Cube(f32.Point{X: 0, Y: -ne * c}, f32.Point{X: -ne + ne*c, Y: -ne}, f32.Point{X: -ne, Y: -ne}) // NE
+	p.Line(f32.Point{X: -w + ne + nw, Y: 0})
+	p.Cube(f32.Point{X: -nw * c, Y: 0}, f32.Point{X: -nw, Y: nw - nw*c}, f32.Point{X: -nw, Y: nw}) // NW
+}
+
+// rounder is implemented by directives that follow the outline of a
+// radius directive. withRadius returns the directive with corners r, or
+// false if it has corners of its own.
+type rounder interface {
+	withRadius(r corners) (directive, bool)
+}
+
+func (s borderS) withRadius(r corners) (directive, bool) {
+	if !s.r.isZero() {
+		return s, false
+	}
+	s.r = r
+	return s, true
+}
+
+func (s backgroundS) withRadius(r corners) (directive, bool) {
+	if !s.r.isZero() {
+		return s, false
+	}
+	s.r = r
+	return s, true
+}
+
+// adoptRadius gives the border, bkground and shadow directives of a
+// chain the corners of the nearest radius directive styling the same
+// box, so that they follow the same rounded outline. A directive such
+// as inset or size between them styles a box of another size.
+func adoptRadius(chain []directive) {
+	for i, d := range chain {
+		d, ok := d.(rounder)
+		if !ok {
+			continue
+		}
+		after, dafter := radiusFrom(chain, i, 1)
+		before, dbefore := radiusFrom(chain, i, -1)
+		r := after
+		switch {
+		case dafter < 0 && dbefore < 0:
+			continue
+		case dafter < 0 || dbefore >= 0 && dbefore < dafter:
+			r = before
+		}
+		if d, ok := d.withRadius(r); ok {
+			chain[i] = d
+		}
+	}
+}
+
+// radiusFrom returns the corners of the first radius directive from
+// chain[i] in direction dir that styles the same box, and its distance,
+// or -1 if there is none.
+func radiusFrom(chain []directive, i, dir int) (corners, int) {
+	for j := i + dir; j >= 0 && j < len(chain); j += dir {
+		if r, ok := chain[j].(radiusS); ok {
+			return r.r, (j - i) * dir
+		}
+		if !sameBox(chain[j]) {
+			break
+		}
+	}
+	return corners{}, -1
+}
+
+// sameBox reports whether d keeps the box of the widget it styles, as
+// opposed to directives such as inset or size.
+func sameBox(d directive) bool {
+	switch d := d.(type) {
+	case stateD:
+		return sameBox(d.d)
+	case rounder, textD, opacityS, offsetS, scaleS, rotateS, interactS, clickS, transitionD:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// ScrollAxis selects the axes a scroll directive scrolls along.
+type ScrollAxis uint8
+
+const (
+	ScrollVertical ScrollAxis = 1 << iota
+	ScrollHorizontal
+	ScrollBoth = ScrollVertical | ScrollHorizontal
+)
+
+// Scroll is the typed form of scroll(v/h/both,key(k),scrollbar), where
+// key may be any comparable value but nil.
+func Scroll(axis ScrollAxis, key interface{}, scrollbar bool) Style {
+	if key == nil {
+		panic("fn: Scroll with a nil key")
+	}
+	return styleOf(scrollS{axis, key, scrollbar})
+}
+
+// ScrollOffset returns the scroll offset in pixels of the scroll
+// directive with key.
+func ScrollOffset(key interface{}) image.Point {
+	return scrollerFor(key).off
+}
+
+// ScrollTo sets the scroll offset in pixels of the scroll directive with
+// key. The offset is limited to the content at the next layout.
+func ScrollTo(key interface{}, off image.Point) {
+	scrollerFor(key).off = off
+}
+
+// dragSlop is the distance a mouse press moves before it drags the
+// content, as the touch slop of package gesture.
+var dragSlop = unit.Dp(3)
+
+// scroller is the state of a scroll directive.
+type scroller struct {
+	off  image.Point
+	bars [2]scrollbar
+	// pressed, dragging and last track a drag of the content.
+	pressed, dragging bool
+	last              f32.Point
+}
+
+func scrollerFor(key interface{}) *scroller {
+	return stateFor(scrollState, key, func() interface{} { return new(scroller) }).(*scroller)
+}
+
+// scrollS lays out its widget unconstrained along the scroll axes and
+// shows the part at the scroll offset, clipped to the constraints.
+type scrollS struct {
+	axis      ScrollAxis
+	key       interface{}
+	scrollbar bool
+}
+
+// inf stands for an unbounded constraint, as in layout.List.
+const inf = 1e6
+
+func (s scrollS) Layout(gtx C, w layout.Widget) D {
+	st := scrollerFor(s.key)
+	st.update(gtx, s.axis)
+
+	cs := gtx.Constraints
+	m := op.Record(gtx.Ops)
+	cgtx := gtx
+	if s.axis&ScrollHorizontal != 0 {
+		cgtx.Constraints.Min.X, cgtx.Constraints.Max.X = 0, inf
+	}
+	if s.axis&ScrollVertical != 0 {
+		cgtx.Constraints.Min.Y, cgtx.Constraints.Max.Y = 0, inf
+	}
+	dims := w(cgtx)
+	call := m.Stop()
+
+	content := dims.Size
+	view := cs.Constrain(content)
+	st.off = clampOffset(st.off, content.Sub(view))
+
+	defer op.Push(gtx.Ops).Pop()
+	clip.Rect{Max: view}.Add(gtx.Ops)
+	// The content is nested in the scroll area, as in layout.List.
+	stack := op.Push(gtx.Ops)
+	pointer.Rect(image.Rectangle{Max: view}).Add(gtx.Ops)
+	pointer.InputOp{Tag: st, Grab: st.dragging, Types: pointer.Press | pointer.Drag | pointer.Release | pointer.Scroll}.Add(gtx.Ops)
+	op.Offset(layout.FPt(st.off.Mul(-1))).Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	stack.Pop()
+
+	if s.scrollbar {
+		for _, axis := range []layout.Axis{layout.Horizontal, layout.Vertical} {
+			if s.axis&scrollAxisOf(axis) == 0 {
+				continue
+			}
+			off, size, total := st.off.X, view.X, content.X
+			if axis == layout.Vertical {
+				off, size, total = st.off.Y, view.Y, content.Y
+			}
+			if total <= 0 {
+				continue
+			}
+			start := float32(off) / float32(total)
+			end := float32(off+size) / float32(total)
+			if to, ok := st.bars[axis].layout(gtx, axis, view, start, end); ok {
+				pos := int(to*float32(total) + .5)
+				if axis == layout.Vertical {
+					st.off.Y = pos
+				} else {
+					st.off.X = pos
+				}
+				op.InvalidateOp{}.Add(gtx.Ops)
+			}
+		}
+	}
+
+	res := D{Size: view}
+	if s.axis&ScrollVertical == 0 {
+		res.Baseline = dims.Baseline - (content.Y - view.Y)
+	}
+	return res
+}
+
+// update scrolls by the wheel and drag events since the last layout.
+func (st *scroller) update(gtx C, axis ScrollAxis) {
+	for _, e := range gtx.Events(st) {
+		e, ok := e.(pointer.Event)
+		if !ok {
+			continue
+		}
+		var delta f32.Point
+		switch e.Type {
+		case pointer.Scroll:
+			delta = e.Scroll
+		case pointer.Press:
+			if e.Source == pointer.Mouse && !e.Buttons.Contain(pointer.ButtonLeft) {
+				continue
+			}
+			// Touches drag at once, while mouse presses are left to the
+			// content until they move past the slop, so that clicks and
+			// text selection keep working.
+			st.pressed, st.last = true, e.Position
+			st.dragging = e.Source == pointer.Touch
+			continue
+		case pointer.Drag:
+			if !st.pressed {
+				continue
+			}
+			delta = st.last.Sub(e.Position)
+			if !st.dragging {
+				slop := float64(gtx.Px(dragSlop))
+				if math.Abs(float64(delta.X)) < slop && math.Abs(float64(delta.Y)) < slop {
+					continue
+				}
+				st.dragging = true
+			}
+			st.last = e.Position
+		case pointer.Release, pointer.Cancel:
+			st.pressed, st.dragging = false, false
+			continue
+		}
+		if axis&ScrollHorizontal != 0 {
+			st.off.X += int(math.Round(float64(delta.X)))
+		}
+		if axis&ScrollVertical != 0 {
+			st.off.Y += int(math.Round(float64(delta.Y)))
+		}
+	}
+}
+
+func scrollAxisOf(axis layout.Axis) ScrollAxis {
+	if axis == layout.Vertical {
+		return ScrollVertical
+	}
+	return ScrollHorizontal
+}
+
+// clampOffset limits off to between zero and max.
+func clampOffset(off, max image.Point) image.Point {
+	if off.X > max.X {
+		off.X = max.X
+	}
+	if off.Y > max.Y {
+		off.Y = max.Y
+	}
+	if off.X < 0 {
+		off.X = 0
+	}
+	if off.Y < 0 {
+		off.Y = 0
+	}
+	return off
+}
+
+// scroll compiles the parameters of scroll: v, h or both, key(k) and
+// scrollbar, in any order. The key is required, since the Program is
+// shared by every widget with the same style.
+func (c *compiler) scroll(s section) directive {
+	d := scrollS{axis: ScrollVertical}
+	for _, p := range s.params {
+		switch {
+		case p.call && p.s == "key":
+			if k, ok := c.key(s, p); ok {
+				d.key = k
+			}
+		case p.call:
+			c.errorf(p.off, s.name, "invalid parameter %s(...)", p.s)
+		case p.s == "v":
+			d.axis = ScrollVertical
+		case p.s == "h":
+			d.axis = ScrollHorizontal
+		case p.s == "both":
+			d.axis = ScrollBoth
+		case p.s == "scrollbar":
+			d.scrollbar = true
+		default:
+			c.errorf(p.off, s.name, "invalid parameter %q", p.s)
+		}
+	}
+	if d.key == nil && c.err == nil {
+		c.errorf(s.off, s.name, "missing key(name)")
+	}
+	return d
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+// scrollbar is a scroll indicator along the end of a viewport, which
+// can be pressed and dragged to scroll. Its color is the theme color
+// scrollbar, if defined.
+type scrollbar struct {
+	dragging bool
+}
+
+var scrollbarColor = color.RGBA{A: 0x60}
+
+// layout updates and draws the scrollbar of a viewport of size sz
+// scrolling along axis, where the visible part spans start to end of
+// the content as fractions. If the scrollbar was dragged, it returns the
+// fraction to scroll to and true.
+func (s *scrollbar) layout(gtx C, axis layout.Axis, sz image.Point, start, end float32) (float32, bool) {
+	main, cross := sz.X, sz.Y
+	if axis == layout.Vertical {
+		main, cross = cross, main
+	}
+	visible := end - start
+	to, scrolled := float32(0), false
+	for _, e := range gtx.Events(s) {
+		e, ok := e.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case pointer.Press:
+			s.dragging = true
+		case pointer.Release, pointer.Cancel:
+			s.dragging = false
+			continue
+		case pointer.Drag:
+		default:
+			continue
+		}
+		if !s.dragging || main <= 0 {
+			continue
+		}
+		pos := e.Position.X
+		if axis == layout.Vertical {
+			pos = e.Position.Y
+		}
+		// Center the thumb on the pointer.
+		to, scrolled = pos/float32(main)-visible/2, true
+		if to < 0 {
+			to = 0
+		}
+		if to > 1-visible {
+			to = 1 - visible
+		}
+	}
+	if scrolled {
+		start, end = to, to+visible
+	}
+	if visible >= 1 && !s.dragging {
+		return to, scrolled
+	}
+
+	thick := gtx.Px(unit.Dp(4))
+	margin := gtx.Px(unit.Dp(2))
+	hit := gtx.Px(unit.Dp(16))
+	if hit > cross {
+		hit = cross
+	}
+	defer op.Push(gtx.Ops).Pop()
+	track := image.Rectangle{Min: image.Pt(0, cross-hit), Max: image.Pt(main, cross)}
+	thumb := f32.Rect(float32(main)*start, float32(cross-margin-thick), float32(main)*end, float32(cross-margin))
+	if axis == layout.Vertical {
+		track = image.Rectangle{Min: image.Pt(cross-hit, 0), Max: image.Pt(cross, main)}
+		thumb = f32.Rect(float32(cross-margin-thick), float32(main)*start, float32(cross-margin), float32(main)*end)
+	}
+	col := scrollbarColor
+	if c, ok := CurrentTheme().Colors["scrollbar"]; ok {
+		col = c
+	}
+	drawRect(gtx.Ops, thumb.Min.X, thumb.Min.Y, thumb.Dx(), thumb.Dy(), Fade(gtx, col))
+	pointer.Rect(track).Add(gtx.Ops)
+	pointer.InputOp{Tag: s, Grab: s.dragging, Types: pointer.Press | pointer.Drag | pointer.Release}.Add(gtx.Ops)
+	return to, scrolled
+}
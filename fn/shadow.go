@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/paint"
+)
+
+// Shadow is the typed form of shadow(dx,dy,blur,spread,color).
+func Shadow(dx, dy, blur, spread float32, col color.RGBA) Style {
+	return styleOf(shadowS{dp(dx), dp(dy), dp(blur), dp(spread), colorOf(col), corners{}})
+}
+
+// RoundedShadow is the typed form of shadow(dx,dy,blur,spread,color) in
+// a style with a radius.
+func RoundedShadow(dx, dy, blur, spread float32, col color.RGBA, r Corners) Style {
+	return styleOf(shadowS{dp(dx), dp(dy), dp(blur), dp(spread), colorOf(col), r.corners()})
+}
+
+// Elevation is the typed form of elevation(n).
+func Elevation(n float32) Style {
+	return styleOf(elevation(n))
+}
+
+// elevation returns the shadow of a surface raised n dp above the
+// background.
+func elevation(n float32) shadowS {
+	if n < 0 {
+		n = 0
+	}
+	return shadowS{dy: dp(n / 2), blur: dp(n), col: colorOf(color.RGBA{A: 0x50})}
+}
+
+// shadowS paints a soft shadow behind a widget. The blurred mask is
+// generated on the CPU and cached by size, and faded by the opacity at
+// paint time.
+type shadowS struct {
+	dx, dy, blur, spread length
+	col                  colorRef
+	r                    corners
+}
+
+func (s shadowS) withRadius(r corners) (directive, bool) {
+	if !s.r.isZero() {
+		return s, false
+	}
+	s.r = r
+	return s, true
+}
+
+func (s shadowS) Layout(gtx C, w layout.Widget) D {
+	m := op.Record(gtx.Ops)
+	dims := w(gtx)
+	call := m.Stop()
+
+	spread, blur := s.spread.px(gtx), s.blur.px(gtx)
+	if blur < 0 {
+		blur = 0
+	}
+	sz := dims.Size.Add(image.Pt(2*spread, 2*spread))
+	if a := opacity(gtx); sz.X > 0 && sz.Y > 0 && a > 0 {
+		fsz := layout.FPt(dims.Size)
+		rr := s.r.px(gtx, fsz)
+		if !s.r.isZero() {
+			sp := float32(spread)
+			rr = rradii{rr.nw + sp, rr.ne + sp, rr.se + sp, rr.sw + sp}
+		}
+		img, margin := shadowMask(shadowKey{size: sz, blur: blur, r: rr, col: s.col.rgba()}, a)
+		off := image.Pt(s.dx.px(gtx)-spread-margin, s.dy.px(gtx)-spread-margin)
+		stack := op.Push(gtx.Ops)
+		img.Add(gtx.Ops)
+		isz := img.Size()
+		paint.PaintOp{Rect: f32.Rectangle{
+			Min: layout.FPt(off),
+			Max: layout.FPt(off.Add(isz)),
+		}}.Add(gtx.Ops)
+		stack.Pop()
+	}
+	call.Add(gtx.Ops)
+	return dims
+}
+
+type shadowKey struct {
+	size image.Point
+	blur int
+	r    rradii
+	col  color.RGBA
+	// fade is the opacity of a faded mask, or 0 for the mask itself.
+	fade uint8
+}
+
+var shadows = newImageCache(64)
+
+// shadowMask returns the cached shadow image for k faded by alpha,
+// along with the margin the blur adds around each side of the shape.
+func shadowMask(k shadowKey, alpha float32) (paint.ImageOp, int) {
+	margin := (3*k.blur + 1) / 2
+	mask := shadows.lookup(imageKey{shadow: k}, func() image.Image {
+		return renderShadow(k, margin)
+	})
+	if alpha >= 1 {
+		return mask.op, margin
+	}
+	// Fading the mask is cheaper than blurring it again, which matters
+	// while the opacity is animated.
+	k.fade = uint8(alpha*0xff + .5)
+	return shadows.get(imageKey{shadow: k}, func() image.Image {
+		return fadeImage(mask.src.(*image.RGBA), float32(k.fade)/0xff)
+	}), margin
+}
+
+// fadeImage returns a copy of the premultiplied img with its pixels
+// scaled by alpha.
+func fadeImage(img *image.RGBA, alpha float32) *image.RGBA {
+	faded := image.NewRGBA(img.Rect)
+	for i, p := range img.Pix {
+		faded.Pix[i] = uint8(float32(p)*alpha + .5)
+	}
+	return faded
+}
+
+// renderShadow rasterizes a rounded rectangle of size k.size blurred by
+// a gaussian with a standard deviation of half the blur radius.
+func renderShadow(k shadowKey, margin int) *image.RGBA {
+	bounds := image.Rectangle{Max: k.size.Add(image.Pt(2*margin, 2*margin))}
+	img := image.NewRGBA(bounds)
+	hw, hh := float64(k.size.X)/2, float64(k.size.Y)/2
+	cx, cy := float64(margin)+hw, float64(margin)+hh
+	sigma := float64(k.blur) / 2
+	for y := 0; y < bounds.Max.Y; y++ {
+		for x := 0; x < bounds.Max.X; x++ {
+			px, py := float64(x)+.5-cx, float64(y)+.5-cy
+			var r float32
+			switch {
+			case px < 0 && py < 0:
+				r = k.r.nw
+			case py < 0:
+				r = k.r.ne
+			case px < 0:
+				r = k.r.sw
+			default:
+				r = k.r.se
+			}
+			d := roundedDist(px, py, hw, hh, float64(r))
+			var a float64
+			if sigma > 0 {
+				a = 0.5 * math.Erfc(d/(sigma*math.Sqrt2))
+			} else {
+				a = math.Max(0, math.Min(1, 0.5-d))
+			}
+			// The color is premultiplied, as are the pixels.
+			i := img.PixOffset(x, y)
+			img.Pix[i+0] = uint8(float64(k.col.R)*a + .5)
+			img.Pix[i+1] = uint8(float64(k.col.G)*a + .5)
+			img.Pix[i+2] = uint8(float64(k.col.B)*a + .5)
+			img.Pix[i+3] = uint8(float64(k.col.A)*a + .5)
+		}
+	}
+	return img
+}
+
+// roundedDist returns the signed distance from (x, y) to a rounded
+// rectangle centered at the origin with half size (hw, hh) and corner
+// radius r.
+func roundedDist(x, y, hw, hh, r float64) float64 {
+	qx := math.Abs(x) - hw + r
+	qy := math.Abs(y) - hh + r
+	outside := math.Hypot(math.Max(qx, 0), math.Max(qy, 0))
+	inside := math.Min(math.Max(qx, qy), 0)
+	return outside + inside - r
+}
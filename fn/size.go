@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"strconv"
+	"strings"
+
+	"gioui.org/layout"
+)
+
+// MinSize is the typed form of minsize(width,height).
+func MinSize(width, height float32) Style {
+	return styleOf(minSizeS{dpExtent(width), dpExtent(height)})
+}
+
+// MaxSize is the typed form of maxsize(width,height).
+func MaxSize(width, height float32) Style {
+	return styleOf(maxSizeS{dpExtent(width), dpExtent(height)})
+}
+
+// Width is the typed form of width(w).
+func Width(w float32) Style {
+	return styleOf(exactS{x: dpExtent(w)})
+}
+
+// Height is the typed form of height(h).
+func Height(h float32) Style {
+	return styleOf(exactS{y: dpExtent(h)})
+}
+
+// WidthPercent is the typed form of width(p%).
+func WidthPercent(p float32) Style {
+	return styleOf(exactS{x: percent(p)})
+}
+
+// HeightPercent is the typed form of height(p%).
+func HeightPercent(p float32) Style {
+	return styleOf(exactS{y: percent(p)})
+}
+
+// FillX is the typed form of fillx.
+func FillX() Style {
+	return styleOf(fillS{x: true})
+}
+
+// FillY is the typed form of filly.
+func FillY() Style {
+	return styleOf(fillS{y: true})
+}
+
+// FillXY is the typed form of fill.
+func FillXY() Style {
+	return styleOf(fillS{x: true, y: true})
+}
+
+// Aspect is the typed form of aspect(ratio), where ratio is the width
+// divided by the height.
+func Aspect(ratio float32) Style {
+	return styleOf(aspectS{ratio})
+}
+
+// extent is a size in dp, or a percentage of the maximum constraint.
+// Zero extents leave the constraint unchanged.
+type extent struct {
+	l   length
+	pct float32
+	rel bool
+}
+
+func dpExtent(v float32) extent {
+	return extent{l: dp(v)}
+}
+
+func percent(p float32) extent {
+	return extent{pct: p, rel: true}
+}
+
+// px returns the extent in pixels, relative to max for percentages, and
+// whether it is set.
+func (e extent) px(gtx C, max int) (int, bool) {
+	if e.rel {
+		return int(float32(max)*e.pct/100 + .5), e.pct > 0
+	}
+	return e.l.px(gtx), e.l.value().V > 0
+}
+
+// exactS fixes the width or height of a widget, like size.
+type exactS struct {
+	x, y extent
+}
+
+func (s exactS) Layout(gtx C, w layout.Widget) D {
+	cs := &gtx.Constraints
+	if v, ok := s.x.px(gtx, cs.Max.X); ok {
+		v = clamp(v, cs.Min.X, cs.Max.X)
+		cs.Min.X, cs.Max.X = v, v
+	}
+	if v, ok := s.y.px(gtx, cs.Max.Y); ok {
+		v = clamp(v, cs.Min.Y, cs.Max.Y)
+		cs.Min.Y, cs.Max.Y = v, v
+	}
+	return w(gtx)
+}
+
+// minSizeS raises the minimum constraints of a widget.
+type minSizeS struct {
+	width, height extent
+}
+
+func (s minSizeS) Layout(gtx C, w layout.Widget) D {
+	cs := &gtx.Constraints
+	if v, ok := s.width.px(gtx, cs.Max.X); ok && v > cs.Min.X {
+		cs.Min.X = clamp(v, cs.Min.X, cs.Max.X)
+	}
+	if v, ok := s.height.px(gtx, cs.Max.Y); ok && v > cs.Min.Y {
+		cs.Min.Y = clamp(v, cs.Min.Y, cs.Max.Y)
+	}
+	return w(gtx)
+}
+
+// maxSizeS lowers the maximum constraints of a widget.
+type maxSizeS struct {
+	width, height extent
+}
+
+func (s maxSizeS) Layout(gtx C, w layout.Widget) D {
+	cs := &gtx.Constraints
+	if v, ok := s.width.px(gtx, cs.Max.X); ok && v < cs.Max.X {
+		cs.Max.X = clamp(v, cs.Min.X, cs.Max.X)
+	}
+	if v, ok := s.height.px(gtx, cs.Max.Y); ok && v < cs.Max.Y {
+		cs.Max.Y = clamp(v, cs.Min.Y, cs.Max.Y)
+	}
+	return w(gtx)
+}
+
+// fillS expands a widget to its maximum constraints.
+type fillS struct {
+	x, y bool
+}
+
+func (s fillS) Layout(gtx C, w layout.Widget) D {
+	if s.x {
+		gtx.Constraints.Min.X = gtx.Constraints.Max.X
+	}
+	if s.y {
+		gtx.Constraints.Min.Y = gtx.Constraints.Max.Y
+	}
+	return w(gtx)
+}
+
+// aspectS fixes the ratio of the width to the height of a widget. An
+// axis with an exact constraint determines the other; otherwise the
+// widget is made as large as the bounded maximum constraints allow. A
+// widget unbounded on both axes, such as in a scrolled list, keeps its
+// natural size.
+type aspectS struct {
+	ratio float32
+}
+
+func (s aspectS) Layout(gtx C, w layout.Widget) D {
+	if s.ratio <= 0 {
+		return w(gtx)
+	}
+	cs := gtx.Constraints
+	byWidth := image.Pt(cs.Max.X, int(float32(cs.Max.X)/s.ratio+.5))
+	byHeight := image.Pt(int(float32(cs.Max.Y)*s.ratio+.5), cs.Max.Y)
+	var sz image.Point
+	switch {
+	case cs.Min.X == cs.Max.X:
+		sz = byWidth
+	case cs.Min.Y == cs.Max.Y:
+		sz = byHeight
+	case cs.Max.Y >= inf && cs.Max.X >= inf:
+		return w(gtx)
+	case cs.Max.Y >= inf:
+		sz = byWidth
+	case cs.Max.X >= inf:
+		sz = byHeight
+	default:
+		sz = byWidth
+		if sz.Y > cs.Max.Y {
+			sz = byHeight
+		}
+	}
+	gtx.Constraints = layout.Exact(cs.Constrain(sz))
+	return w(gtx)
+}
+
+func clamp(v, min, max int) int {
+	if v > max {
+		v = max
+	}
+	if v < min {
+		v = min
+	}
+	return v
+}
+
+// extent parses a length parameter or a percentage such as 50%.
+func (c *compiler) extent(s section, i int) extent {
+	p := s.params[i]
+	if !p.call && strings.HasSuffix(p.s, "%") {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(p.s, "%"), 32)
+		if err != nil {
+			c.errorf(p.off, s.name, "invalid percentage %q", p.s)
+		}
+		return percent(float32(v))
+	}
+	return extent{l: c.length(s, i)}
+}
+
+// ratio parses a ratio such as 16:9 or 1.5.
+func (c *compiler) ratio(s section, i int) float32 {
+	p := s.params[i]
+	colon := strings.IndexByte(p.s, ':')
+	if colon < 0 {
+		return c.float(s, i)
+	}
+	x, err1 := strconv.ParseFloat(strings.TrimSpace(p.s[:colon]), 32)
+	y, err2 := strconv.ParseFloat(strings.TrimSpace(p.s[colon+1:]), 32)
+	if err1 != nil || err2 != nil || p.call || x <= 0 || y <= 0 {
+		c.errorf(p.off, s.name, "invalid ratio %q", p.s)
+		return 0
+	}
+	return float32(x / y)
+}
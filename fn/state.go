@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import "sync"
+
+// states holds the state fn keeps on behalf of callers, such as the
+// scroll position of a list, by kind and caller key.
+var states = struct {
+	sync.Mutex
+	m map[stateKey]interface{}
+}{m: make(map[stateKey]interface{})}
+
+type stateKind uint8
+
+const (
+	listState stateKind = iota
+	scrollState
+	interactState
+	clickState
+	animState
+)
+
+type stateKey struct {
+	kind stateKind
+	key  interface{}
+}
+
+// stateFor returns the state of kind for key, creating it with init on
+// first use.
+func stateFor(kind stateKind, key interface{}, init func() interface{}) interface{} {
+	k := stateKey{kind, key}
+	states.Lock()
+	defer states.Unlock()
+	s, ok := states.m[k]
+	if !ok {
+		s = init()
+		states.m[k] = s
+	}
+	return s
+}
+
+// Forget discards the state fn keeps for key, such as the scroll
+// position of a list. Later uses of key start afresh.
+func Forget(key interface{}) {
+	states.Lock()
+	defer states.Unlock()
+	for k := range states.m {
+		if k.key == key {
+			delete(states.m, k)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// styles holds the named styles of the loaded stylesheets, used as
+// @name.
+var styles = struct {
+	sync.RWMutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+// Stylesheet is a loaded .giox file of classes, theme variables and
+// named styles, one definition per line:
+//
+//	// Comments start with //.
+//	$primary = #3f51b5
+//	$gap = 8
+//	.card = inset($gap);border(1,1,1,1,$primary);inset(4)
+//	@users = vflex(gap(4));.card
+//
+// $name defines a theme spacing in dp if the value is a number, and a
+// theme color otherwise, such as #3f51b5, rgba(0,0,0,0.5) or red, since
+// a bare hex color would read as a number. .name defines a class and
+// @name a style that Format and Widget accept in place of a style
+// string, as in fn.Format(gtx, "@users", ...). Lines starting with a
+// space or tab continue the previous definition.
+//
+// Theme variables are layered over the current theme each time the
+// sheet is applied, replacing only the variables of its previous
+// version. A class may be defined by one sheet or by Define, not both.
+type Stylesheet struct {
+	path string
+
+	mu sync.Mutex
+	// mod and size identify the version of the file last read.
+	mod  time.Time
+	size int64
+	// colors, spacing, classes and styles are the names the sheet
+	// defined.
+	colors, spacing []string
+	classes, styles []string
+	err             error
+	stop            chan struct{}
+}
+
+// sheet is a parsed stylesheet.
+type sheet struct {
+	path    string
+	colors  map[string]color.RGBA
+	spacing map[string]float32
+	classes map[string]string
+	styles  map[string]string
+	// order lists the classes in definition order, and lines the line
+	// of each.
+	order []string
+	lines map[string]int
+}
+
+// LoadStylesheet loads and applies the stylesheet at path. It returns
+// an error, and applies nothing, if the file cannot be read or has a
+// malformed definition.
+func LoadStylesheet(path string) (*Stylesheet, error) {
+	s := &Stylesheet{path: path}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Watch polls the file of the sheet every interval, reloading it when
+// it changes and calling invalidate, such as the Invalidate method of
+// the window, once the new sheet is applied. A file that fails to load
+// leaves the last good sheet in place; its error is logged according to
+// the error mode and reported by Err.
+func (s *Stylesheet) Watch(interval time.Duration, invalidate func()) {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	s.mu.Unlock()
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+			if !s.changed() {
+				continue
+			}
+			if err := s.reload(); err != nil {
+				if currentErrorMode() != Ignore {
+					log.Println(err)
+				}
+				continue
+			}
+			if invalidate != nil {
+				invalidate()
+			}
+		}
+	}()
+}
+
+// Close stops watching the file. The sheet stays applied.
+func (s *Stylesheet) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Err returns the error of the last attempt to load the file, or nil if
+// it succeeded.
+func (s *Stylesheet) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// changed reports whether the file differs from the version last read.
+func (s *Stylesheet) changed() bool {
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !fi.ModTime().Equal(s.mod) || fi.Size() != s.size
+}
+
+// reload reads, checks and applies the file.
+func (s *Stylesheet) reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fi, err := os.Stat(s.path)
+	if err == nil {
+		s.mod, s.size = fi.ModTime(), fi.Size()
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		s.err = err
+		return err
+	}
+	sh, err := parseSheet(s.path, data)
+	if err != nil {
+		s.err = err
+		return err
+	}
+	if err := s.apply(sh); err != nil {
+		s.err = err
+		return err
+	}
+	s.err = nil
+	return nil
+}
+
+// apply replaces the definitions of the previous version of the sheet
+// with those of sh. It applies nothing if a class of sh is defined
+// elsewhere.
+func (s *Stylesheet) apply(sh *sheet) error {
+	classes.Lock()
+	for _, name := range sh.order {
+		if _, defined := classes.m[name]; defined && classes.sheets[name] != s {
+			classes.Unlock()
+			return fmt.Errorf("%s:%d: class .%s is already defined", sh.path, sh.lines[name], name)
+		}
+	}
+	for _, name := range s.classes {
+		if classes.sheets[name] == s {
+			delete(classes.m, name)
+			delete(classes.sheets, name)
+		}
+	}
+	s.classes = s.classes[:0]
+	for _, name := range sh.order {
+		classes.m[name] = sh.classes[name]
+		classes.sheets[name] = s
+		s.classes = append(s.classes, name)
+	}
+	classes.Unlock()
+
+	styles.Lock()
+	for _, name := range s.styles {
+		delete(styles.m, name)
+	}
+	s.styles = s.styles[:0]
+	for name, style := range sh.styles {
+		styles.m[name] = style
+		s.styles = append(s.styles, name)
+	}
+	styles.Unlock()
+
+	SetTheme(s.layer(CurrentTheme(), sh))
+	resetPrograms()
+	return nil
+}
+
+// layer returns a copy of cur without the variables of the previous
+// version of the sheet, and with those of sh.
+func (s *Stylesheet) layer(cur *Theme, sh *sheet) *Theme {
+	t := NewTheme()
+	t.Material = cur.Material
+	for k, v := range cur.Colors {
+		t.Colors[k] = v
+	}
+	for k, v := range cur.Spacing {
+		t.Spacing[k] = v
+	}
+	for k, v := range cur.Radii {
+		t.Radii[k] = v
+	}
+	for _, k := range s.colors {
+		delete(t.Colors, k)
+	}
+	for _, k := range s.spacing {
+		delete(t.Spacing, k)
+	}
+	s.colors, s.spacing = s.colors[:0], s.spacing[:0]
+	for k, v := range sh.colors {
+		t.Colors[k] = v
+		s.colors = append(s.colors, k)
+	}
+	for k, v := range sh.spacing {
+		t.Spacing[k] = v
+		s.spacing = append(s.spacing, k)
+	}
+	return t
+}
+
+// parseSheet parses and checks the stylesheet data read from path.
+func parseSheet(path string, data []byte) (*sheet, error) {
+	sh := &sheet{
+		path:    path,
+		colors:  make(map[string]color.RGBA),
+		spacing: make(map[string]float32),
+		classes: make(map[string]string),
+		styles:  make(map[string]string),
+		lines:   make(map[string]int),
+	}
+	type def struct {
+		line        int
+		name, value string
+	}
+	var defs []def
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "//"):
+			continue
+		case line[0] == ' ' || line[0] == '\t':
+			if len(defs) == 0 {
+				return nil, fmt.Errorf("%s:%d: continuation without a definition", path, n)
+			}
+			defs[len(defs)-1].value += trimmed
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("%s:%d: missing =", path, n)
+		}
+		name := strings.TrimSpace(line[:eq])
+		if len(name) < 2 || !strings.ContainsRune("$.@", rune(name[0])) || !isName(name[1:]) {
+			return nil, fmt.Errorf("%s:%d: invalid name %q", path, n, name)
+		}
+		defs = append(defs, def{n, name, strings.TrimSpace(line[eq+1:])})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, d := range defs {
+		name := d.name[1:]
+		switch d.name[0] {
+		case '$':
+			if v, err := strconv.ParseFloat(d.value, 32); err == nil {
+				sh.spacing[name] = float32(v)
+			} else if c, ok := sheetColor(d.value); ok {
+				sh.colors[name] = c
+			} else {
+				return nil, fmt.Errorf("%s:%d: invalid value %q for %s", path, d.line, d.value, d.name)
+			}
+		case '.':
+			if _, dup := sh.classes[name]; !dup {
+				sh.order = append(sh.order, name)
+				sh.lines[name] = d.line
+			}
+			sh.classes[name] = d.value
+		case '@':
+			sh.styles[name] = d.value
+		}
+	}
+	// Check the classes and styles against each other.
+	for _, d := range defs {
+		var err *SyntaxError
+		switch d.name[0] {
+		case '.':
+			c := &compiler{style: d.value, defs: sh.classes, expanding: []string{d.name[1:]}}
+			c.classBody()
+			err = c.err
+		case '@':
+			c := &compiler{style: d.value, defs: sh.classes, styles: sh.styles}
+			if p := c.program(); p.err != nil {
+				err = p.err.(*SyntaxError)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, d.line, err)
+		}
+	}
+	return sh, nil
+}
+
+// sheetColor parses the color value of a theme variable.
+func sheetColor(v string) (color.RGBA, bool) {
+	c := &compiler{style: v}
+	s, _ := c.atom()
+	p := param{s: s}
+	if c.peek() == '(' {
+		p.call = true
+		p.args = c.args(s)
+	}
+	c.space()
+	if c.err != nil || c.pos < len(v) {
+		return color.RGBA{}, false
+	}
+	return parseColor(p)
+}
+
+// named returns the named style of a stylesheet.
+func (c *compiler) named(name string) (string, bool) {
+	if style, ok := c.styles[name]; ok {
+		return style, true
+	}
+	styles.RLock()
+	defer styles.RUnlock()
+	style, ok := styles.m[name]
+	return style, ok
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestStylesheet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "giox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.giox")
+	write := func(src string) {
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := CurrentTheme()
+	defer SetTheme(base)
+
+	write(`// Test sheet.
+$accent = #ff0000
+$gap = 6
+.sheetpad = inset($gap)
+@sheetrow = hflex;.sheetpad;
+	bkground($accent)
+`)
+	s, err := LoadStylesheet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got := CurrentTheme().Colors["accent"]; got != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("accent = %v", got)
+	}
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(400, 400)}}
+	if dims := Format(gtx, "@sheetrow", Child("", box)); dims.Size != image.Pt(60, 36) {
+		t.Errorf("@sheetrow size %v, want (60,36)", dims.Size)
+	}
+
+	// A broken sheet keeps the last good one.
+	write("$gap = 10\n.sheetpad = inset($gap\n")
+	if err := s.reload(); err == nil || !strings.Contains(err.Error(), "app.giox:2") {
+		t.Errorf("reload of a broken sheet = %v", err)
+	}
+	if s.Err() == nil {
+		t.Error("Err is nil after a broken reload")
+	}
+	if v := CurrentTheme().Spacing["gap"]; v != 6 {
+		t.Errorf("gap = %v after a broken reload, want 6", v)
+	}
+
+	// Sheets layer their variables over the current theme, and a class
+	// belongs to a single sheet or to Define.
+	other := filepath.Join(dir, "other.giox")
+	if err := ioutil.WriteFile(other, []byte("$other = 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadStylesheet(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(other, []byte("$other = 4\n.sheetpad = inset(1)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadStylesheet(other); err == nil || !strings.Contains(err.Error(), "other.giox:2") {
+		t.Errorf("sheet redefining a class loaded with %v", err)
+	}
+	if err := Define("sheetpad", "inset(2)"); err == nil {
+		t.Error("Define redefined a class of a sheet")
+	}
+	th := CurrentTheme()
+	later := NewTheme()
+	for k, v := range th.Colors {
+		later.Colors[k] = v
+	}
+	for k, v := range th.Spacing {
+		later.Spacing[k] = v
+	}
+	later.Colors["later"] = color.RGBA{B: 0xff, A: 0xff}
+	SetTheme(later)
+
+	// Fixing the file reloads it and invalidates.
+	invalidated := make(chan struct{}, 1)
+	s.Watch(5*time.Millisecond, func() {
+		select {
+		case invalidated <- struct{}{}:
+		default:
+		}
+	})
+	write("$gap = 10\n.sheetpad = inset($gap)\n")
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	select {
+	case <-invalidated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no invalidation after the sheet changed")
+	}
+	if s.Err() != nil {
+		t.Errorf("Err = %v after a good reload", s.Err())
+	}
+	if v := CurrentTheme().Spacing["gap"]; v != 10 {
+		t.Errorf("gap = %v after reloading, want 10", v)
+	}
+	th = CurrentTheme()
+	if _, ok := th.Colors["accent"]; ok {
+		t.Error("accent still defined after it was removed from the sheet")
+	}
+	if _, ok := th.Colors["later"]; !ok || th.Spacing["other"] != 3 {
+		t.Errorf("reloading dropped variables defined elsewhere: %v, %v", th.Colors, th.Spacing)
+	}
+	if err := Validate("@sheetrow"); err == nil {
+		t.Error("@sheetrow still defined after it was removed from the sheet")
+	}
+}
+
+func TestStylesheetErrors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"$a = 1\n$b = nocolor\n", ":2: invalid value"},
+		{"card = inset(4)\n", ":1: invalid name"},
+		{".a = inset(4)\n  \n.b\n", ":3: missing ="},
+		{"  inset(4)\n", ":1: continuation"},
+		{".a = .b\n.b = .a\n", ":1: "},
+		{"@s = hflex;.missing\n", "undefined class"},
+	}
+	for _, test := range tests {
+		_, err := parseSheet("t.giox", []byte(test.src))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: error %v, want %q", test.src, err, test.err)
+		}
+	}
+}
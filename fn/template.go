@@ -0,0 +1,648 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"fmt"
+	"image"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+)
+
+// Template is a tree of containers and widgets described as text and
+// laid out against a data value, for example
+//
+//	vflex {
+//		hflex(middle);inset(8) {
+//			img(avatar);rounded(36)
+//			f;text(name, body1)
+//		}
+//		each(commits) { text(message, caption);inset(8) }
+//		if(!commits) { text("No commits", caption) }
+//	}
+//
+// A block { ... } holds the children of the container before it, which
+// are separated by ';' or new lines. A child consists of its prefixes,
+// such as f or self(end), a container with a block or one of the
+// widgets below, and its directives. Directives after a widget belong to
+// it, so a new child starts with its prefixes or its widget. Comments
+// start with //.
+//
+// The widgets take the value of a field of the data, named by a path
+// such as name or user.name, where . is the data itself. Fields are
+// exported struct fields, matched regardless of case, or the values of
+// maps with string keys.
+//
+//	text(field) or text(field,size) shows a value as Text, in the
+//	typography of its directives, with a size such as h6, body1 or
+//	caption as in font(size). A quoted field such as "Users" is shown
+//	as is.
+//	img(field) shows an image.Image or paint.ImageOp, sized to the
+//	constraints.
+//	slot(field) lays out a layout.Widget, so that templates and
+//	hand-written widgets compose.
+//
+// each(field) { ... } repeats its block for every element of a slice,
+// with the element as data. The children are passed to the container
+// through Items, so a list builds only those it shows. if(field) { ... }
+// and if(!field) { ... } include their block when the field is, or is
+// not, set: true, non-zero, non-empty or non-nil. Both may hold any
+// number of children and nest within each other.
+//
+// Templates are built on Format and ChildSpec. Styles are compiled and
+// cached as usual and the tree is checked when parsed; the data is
+// looked up by reflection every frame.
+type Template struct {
+	src  string
+	root *tnode
+}
+
+type tkind uint8
+
+const (
+	styleNode tkind = iota
+	containerNode
+	textNode
+	imgNode
+	slotNode
+	eachNode
+	ifNode
+)
+
+// tnode is a node of a template.
+type tnode struct {
+	kind tkind
+	off  int
+	// pre, cont and dirs are the texts of the prefix, container and
+	// directive sections of the node.
+	pre  []string
+	cont string
+	dirs []string
+	// style is the Format style of a container, and child the child
+	// style of the node.
+	style, child string
+
+	path    []string
+	literal string
+	quoted  bool
+	not     bool
+	// scale is the typography of a text node, if set.
+	scale float32
+
+	kids  []*tnode
+	block bool
+}
+
+// ParseTemplate parses and checks a template. A malformed template
+// yields a *SyntaxError whose Style is the template.
+func ParseTemplate(src string) (*Template, error) {
+	p := &tparser{src: src, text: stripComments(src)}
+	nodes := p.block(-1, false)
+	if p.err == nil && (len(nodes) != 1 || nodes[0].kind != containerNode) {
+		p.errorf(0, "", "want a single container at the top")
+	}
+	for _, n := range nodes {
+		p.check(n)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &Template{src: src, root: nodes[0]}, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics if the template
+// cannot be parsed.
+func MustParseTemplate(src string) *Template {
+	t, err := ParseTemplate(src)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Layout lays out the template against data.
+func (t *Template) Layout(gtx C, data interface{}) D {
+	return t.root.layout(gtx, reflect.ValueOf(data))
+}
+
+// Widget returns a widget laying out the template against data.
+func (t *Template) Widget(data interface{}) layout.Widget {
+	return func(gtx C) D {
+		return t.Layout(gtx, data)
+	}
+}
+
+// tparser parses a template into nodes.
+type tparser struct {
+	src string
+	// text is src without comments.
+	text string
+	pos  int
+	err  *SyntaxError
+}
+
+func (p *tparser) errorf(off int, name, format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = &SyntaxError{Style: p.src, Offset: off, Name: name, Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+// block parses nodes up to the } closing the block opened at open, or
+// to the end of the template at the top level.
+func (p *tparser) block(open int, nested bool) []*tnode {
+	var nodes []*tnode
+	var cur *tnode
+	for p.err == nil {
+		start := p.pos
+		depth, quoted := 0, false
+	scan:
+		for ; p.pos < len(p.text); p.pos++ {
+			switch b := p.text[p.pos]; {
+			case b == '"':
+				quoted = !quoted
+			case quoted:
+			case b == '(':
+				depth++
+			case b == ')':
+				depth--
+			case depth == 0 && strings.IndexByte(";{}\n", b) >= 0:
+				break scan
+			}
+		}
+		text := strings.TrimSpace(p.text[start:p.pos])
+		if text != "" {
+			off := start + strings.Index(p.text[start:p.pos], text)
+			cur = p.section(&nodes, cur, text, off)
+		}
+		if p.pos >= len(p.text) {
+			if nested {
+				p.errorf(open, "{", "missing }")
+			}
+			return nodes
+		}
+		b := p.text[p.pos]
+		p.pos++
+		switch b {
+		case '{':
+			if cur == nil || cur.block || (cur.kind != containerNode && cur.kind != eachNode && cur.kind != ifNode) {
+				p.errorf(p.pos-1, "{", "block without a container, each or if")
+				return nodes
+			}
+			cur.kids = p.block(p.pos-1, true)
+			cur.block = true
+			cur = nil
+		case '}':
+			if !nested {
+				p.errorf(p.pos-1, "}", "unexpected }")
+			}
+			return nodes
+		}
+	}
+	return nodes
+}
+
+// section adds the section text at off to the current node, or to a new
+// node it starts, and returns the current node.
+func (p *tparser) section(nodes *[]*tnode, cur *tnode, text string, off int) *tnode {
+	// Mask the text of literals, which may hold delimiters.
+	masked := []byte(text)
+	quoted := false
+	for i, b := range masked {
+		if b == '"' {
+			quoted = !quoted
+		} else if quoted {
+			masked[i] = '_'
+		}
+	}
+	c := &compiler{style: string(masked)}
+	sec, _ := c.section()
+	if c.err != nil {
+		err := *c.err
+		err.Style, err.Offset = p.src, off+err.Offset
+		p.err = &err
+		return cur
+	}
+	add := func(kind tkind) *tnode {
+		n := &tnode{kind: kind, off: off}
+		*nodes = append(*nodes, n)
+		return n
+	}
+	control := cur != nil && (cur.kind == eachNode || cur.kind == ifNode)
+	if control && !cur.block {
+		p.errorf(off, sec.name, "%s takes a block and no styles", cur.cont)
+		return cur
+	}
+	widget := cur != nil && cur.kind != styleNode
+	_, isCont := lookupContainer(sec.name)
+	switch {
+	case sec.state != "":
+		if cur == nil || control {
+			cur = add(styleNode)
+		}
+		cur.dirs = append(cur.dirs, text)
+	case sec.name == "text" || sec.name == "img" || sec.name == "slot":
+		if cur == nil || widget {
+			cur = add(styleNode)
+		}
+		cur.off = off
+		p.widget(cur, sec, text)
+	case sec.name == "each" || sec.name == "if":
+		cur = add(eachNode)
+		if sec.name == "if" {
+			cur.kind = ifNode
+		}
+		cur.cont = sec.name
+		if !c.arity(sec, 1) {
+			p.errorf(off, sec.name, "want 1 parameter, got %d", len(sec.params))
+			return cur
+		}
+		path := sec.params[0].s
+		if sec.name == "if" && strings.HasPrefix(path, "!") {
+			cur.not, path = true, strings.TrimSpace(path[1:])
+		}
+		cur.path = p.path(sec, path, off)
+	case isPrefix(sec.name):
+		if cur == nil || widget || len(cur.dirs) > 0 {
+			cur = add(styleNode)
+		}
+		cur.pre = append(cur.pre, text)
+	case isCont:
+		if cur == nil || widget {
+			cur = add(styleNode)
+		}
+		cur.kind, cur.cont, cur.off = containerNode, text, off
+	default:
+		if cur == nil || control {
+			cur = add(styleNode)
+		}
+		cur.dirs = append(cur.dirs, text)
+	}
+	return cur
+}
+
+// widget compiles a text, img or slot section of text into n.
+func (p *tparser) widget(n *tnode, sec section, text string) {
+	switch sec.name {
+	case "text":
+		n.kind = textNode
+		if len(sec.params) != 1 && len(sec.params) != 2 {
+			p.errorf(sec.off+n.off, sec.name, "want 1 or 2 parameters, got %d", len(sec.params))
+			return
+		}
+		if len(sec.params) == 2 {
+			scale, ok := typography[sec.params[1].s]
+			if !ok {
+				p.errorf(sec.params[1].off+n.off, sec.name, "unknown text size %q", sec.params[1].s)
+			}
+			n.scale = scale
+		}
+		if arg := sec.params[0]; strings.HasPrefix(arg.s, `"`) {
+			s := text[arg.off : arg.off+len(arg.s)]
+			if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+				p.errorf(arg.off+n.off, sec.name, "unterminated text %s", s)
+			}
+			n.literal, n.quoted = strings.Trim(s, `"`), true
+			return
+		}
+	case "img":
+		n.kind = imgNode
+	case "slot":
+		n.kind = slotNode
+	}
+	if sec.name != "text" && len(sec.params) != 1 {
+		p.errorf(sec.off+n.off, sec.name, "want 1 parameter, got %d", len(sec.params))
+		return
+	}
+	n.path = p.path(sec, sec.params[0].s, n.off)
+}
+
+// path parses a field path such as user.name, or . for the data itself.
+func (p *tparser) path(sec section, s string, off int) []string {
+	if s == "." {
+		return nil
+	}
+	path := strings.Split(s, ".")
+	for _, f := range path {
+		if !isName(f) {
+			p.errorf(sec.params[0].off+off, sec.name, "invalid field %q", s)
+			return nil
+		}
+	}
+	return path
+}
+
+// stripComments replaces the // comments of a template with spaces.
+func stripComments(src string) string {
+	b := []byte(src)
+	quoted := false
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '"':
+			quoted = !quoted
+		case !quoted && b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		}
+	}
+	return string(b)
+}
+
+func isPrefix(name string) bool {
+	switch name {
+	case "f", "e", "r", "self", "span", "cell":
+		return true
+	}
+	return false
+}
+
+// check completes and checks the styles of n and its children.
+func (p *tparser) check(n *tnode) {
+	switch n.kind {
+	case styleNode:
+		p.errorf(n.off, "", "missing widget")
+		return
+	case containerNode:
+		n.style = strings.Join(append([]string{n.cont}, n.dirs...), ";")
+		n.child = strings.Join(n.pre, ";")
+	case eachNode, ifNode:
+		if !n.block {
+			p.errorf(n.off, n.cont, "missing block")
+		}
+		for _, k := range n.kids {
+			p.check(k)
+		}
+		return
+	default:
+		n.child = strings.Join(append(n.pre, n.dirs...), ";")
+	}
+	for _, style := range []string{n.style, n.child} {
+		// Only the syntax is checked: theme references resolve when the
+		// template is laid out, in the Theme current then.
+		if err := (&compiler{style: style}).program().err; p.err == nil && style != "" && err != nil {
+			serr := *err.(*SyntaxError)
+			serr.Style, serr.Offset = p.src, n.off
+			p.err = &serr
+			return
+		}
+	}
+	for _, k := range n.kids {
+		p.check(k)
+	}
+}
+
+// layout lays out a container node and its children against data.
+func (n *tnode) layout(gtx C, data reflect.Value) D {
+	children := make([]ChildSpec, 0, len(n.kids))
+	for _, k := range n.kids {
+		children = k.children(children, data)
+	}
+	return Format(gtx, n.style, children...)
+}
+
+// children appends the children n yields for data.
+func (n *tnode) children(specs []ChildSpec, data reflect.Value) []ChildSpec {
+	switch n.kind {
+	case ifNode:
+		if n.holds(data) {
+			for _, k := range n.kids {
+				specs = k.children(specs, data)
+			}
+		}
+		return specs
+	case eachNode:
+		v := n.elems(data)
+		kids := n.kids
+		if k, ok := fixedCount(kids); ok {
+			return append(specs, Items(v.Len()*k, func(i int) ChildSpec {
+				return kids[i%k].spec(v.Index(i / k))
+			}))
+		}
+		// Elements yield varying numbers of children, so find those of
+		// each element by where they start.
+		starts := make([]int, v.Len())
+		total := 0
+		for e := range starts {
+			starts[e] = total
+			total += count(kids, v.Index(e))
+		}
+		return append(specs, Items(total, func(i int) ChildSpec {
+			e := sort.Search(len(starts), func(e int) bool { return starts[e] > i }) - 1
+			return nth(kids, v.Index(e), i-starts[e])
+		}))
+	}
+	return append(specs, n.spec(data))
+}
+
+// holds reports whether the block of an if node is included for data.
+func (n *tnode) holds(data reflect.Value) bool {
+	return truthy(field(data, n.path)) != n.not
+}
+
+// elems returns the slice of an each node, or an empty value.
+func (n *tnode) elems(data reflect.Value) reflect.Value {
+	v := field(data, n.path)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return reflect.ValueOf([0]struct{}{})
+	}
+	return v
+}
+
+// fixedCount returns the number of children nodes yield, if it does not
+// depend on the data.
+func fixedCount(nodes []*tnode) (int, bool) {
+	for _, n := range nodes {
+		if n.kind == ifNode || n.kind == eachNode {
+			return 0, false
+		}
+	}
+	return len(nodes), true
+}
+
+// count returns the number of children nodes yield for data.
+func count(nodes []*tnode, data reflect.Value) int {
+	c := 0
+	for _, n := range nodes {
+		switch n.kind {
+		case ifNode:
+			if n.holds(data) {
+				c += count(n.kids, data)
+			}
+		case eachNode:
+			v := n.elems(data)
+			for e := 0; e < v.Len(); e++ {
+				c += count(n.kids, v.Index(e))
+			}
+		default:
+			c++
+		}
+	}
+	return c
+}
+
+// nth returns child i of those nodes yield for data.
+func nth(nodes []*tnode, data reflect.Value, i int) ChildSpec {
+	for _, n := range nodes {
+		switch n.kind {
+		case ifNode:
+			if !n.holds(data) {
+				continue
+			}
+			if c := count(n.kids, data); i >= c {
+				i -= c
+				continue
+			}
+			return nth(n.kids, data, i)
+		case eachNode:
+			v := n.elems(data)
+			for e := 0; e < v.Len(); e++ {
+				if c := count(n.kids, v.Index(e)); i >= c {
+					i -= c
+					continue
+				}
+				return nth(n.kids, v.Index(e), i)
+			}
+		default:
+			if i == 0 {
+				return n.spec(data)
+			}
+			i--
+		}
+	}
+	return ChildSpec{}
+}
+
+// spec returns the child a container or widget node yields for data.
+func (n *tnode) spec(data reflect.Value) ChildSpec {
+	switch n.kind {
+	case containerNode:
+		return ChildSpec{style: n.child, widget: func(gtx C) D {
+			return n.layout(gtx, data)
+		}}
+	}
+	return ChildSpec{style: n.child, widget: n.widget(data)}
+}
+
+// widget returns the widget of a text, img or slot node.
+func (n *tnode) widget(data reflect.Value) layout.Widget {
+	if n.quoted {
+		return textWidget(n.scale, n.literal)
+	}
+	v := field(data, n.path)
+	if !v.IsValid() {
+		return nil
+	}
+	switch n.kind {
+	case textNode:
+		s, ok := v.Interface().(string)
+		if !ok {
+			s = fmt.Sprint(v.Interface())
+		}
+		return textWidget(n.scale, s)
+	case imgNode:
+		switch img := v.Interface().(type) {
+		case paint.ImageOp:
+			return imageWidget(img)
+		case image.Image:
+			if !reflect.TypeOf(img).Comparable() {
+				return imageWidget(paint.NewImageOp(img))
+			}
+			return imageWidget(templateImages.get(imageKey{img: img}, func() image.Image { return img }))
+		}
+	case slotNode:
+		switch w := v.Interface().(type) {
+		case layout.Widget:
+			return w
+		case func(gtx C) D:
+			return w
+		}
+	}
+	return nil
+}
+
+var templateImages = newImageCache(256)
+
+// textWidget lays out s in the typography of scale, or that of the
+// enclosing directives if zero.
+func textWidget(scale float32, s string) layout.Widget {
+	if scale == 0 {
+		return label(s).Layout
+	}
+	d := textD{textStyle{set: sizeProp, scale: scale}}
+	return func(gtx C) D {
+		return d.Layout(gtx, label(s).Layout)
+	}
+}
+
+// imageWidget lays out an image at its size in dp, within the
+// constraints.
+func imageWidget(img paint.ImageOp) layout.Widget {
+	return func(gtx C) D {
+		sz := img.Size()
+		sz = gtx.Constraints.Constrain(image.Pt(gtx.Px(unit.Dp(float32(sz.X))), gtx.Px(unit.Dp(float32(sz.Y)))))
+		img.Add(gtx.Ops)
+		paint.PaintOp{Rect: f32.Rectangle{Max: layout.FPt(sz)}}.Add(gtx.Ops)
+		return D{Size: sz}
+	}
+}
+
+// field looks up path in data.
+func field(data reflect.Value, path []string) reflect.Value {
+	v := data
+	for _, name := range path {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			v = v.FieldByNameFunc(func(f string) bool {
+				return strings.EqualFold(f, name)
+			})
+			if v.IsValid() && !v.CanInterface() {
+				return reflect.Value{}
+			}
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		default:
+			return reflect.Value{}
+		}
+		if !v.IsValid() {
+			return v
+		}
+	}
+	return v
+}
+
+// truthy reports whether v is set.
+func truthy(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() != 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() != 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() != 0
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() > 0
+	case reflect.Ptr, reflect.Interface, reflect.Func, reflect.Chan:
+		return !v.IsNil()
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+type templateUser struct {
+	Name    string
+	Avatar  layout.Widget
+	Commits []templateCommit
+	Admin   bool
+}
+
+type templateCommit struct {
+	Message string
+	Row     layout.Widget
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl := MustParseTemplate(`
+vflex {
+	hflex(middle);inset(8) {
+		slot(avatar);inset(4)
+		f;slot(badge)
+	}
+	// Rows are 24 high, plus 2 of inset.
+	each(commits) { slot(row);inset(1) }
+	if(!commits) { slot(avatar) }
+	if(admin) { slot(avatar);inset(10) }
+}`)
+	var built []string
+	row := func(msg string) layout.Widget {
+		return func(gtx C) D {
+			built = append(built, msg)
+			return box(gtx)
+		}
+	}
+	u := &templateUser{
+		Name:   "gopher",
+		Avatar: box,
+		Commits: []templateCommit{
+			{Message: "a", Row: row("a")},
+			{Message: "b", Row: row("b")},
+		},
+	}
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(400, 400)}}
+	// The badge is not a field of the user, and lays out nothing.
+	dims := tmpl.Layout(gtx, u)
+	// The header is 24+8+16 high, the rows 2*26.
+	if want := 48 + 2*26; dims.Size.Y != want {
+		t.Errorf("height %d, want %d", dims.Size.Y, want)
+	}
+	if !reflect.DeepEqual(built, []string{"a", "b"}) {
+		t.Errorf("built rows %v, want [a b]", built)
+	}
+
+	// Maps and conditionals.
+	ops.Reset()
+	dims = tmpl.Layout(gtx, map[string]interface{}{"avatar": layout.Widget(box), "admin": true})
+	if want := 48 + 24 + 44; dims.Size.Y != want {
+		t.Errorf("height %d, want %d", dims.Size.Y, want)
+	}
+}
+
+type templateGroup struct {
+	Head layout.Widget
+	Rows []templateCommit
+	Open bool
+}
+
+func TestTemplateNested(t *testing.T) {
+	tmpl := MustParseTemplate(`
+vflex(gap(1)) {
+	each(.) {
+		slot(head)
+		each(rows) { slot(row) }
+		if(open) { slot(head); slot(head) }
+	}
+}`)
+	var built []string
+	row := func(msg string) layout.Widget {
+		return func(gtx C) D {
+			built = append(built, msg)
+			return box(gtx)
+		}
+	}
+	groups := []templateGroup{
+		{Head: row("h0"), Rows: []templateCommit{{Row: row("a")}, {Row: row("b")}}},
+		{Head: row("h1"), Rows: []templateCommit{{Row: row("c")}}, Open: true},
+	}
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(400, 400)}}
+	// The closed group yields no child for its if, and so no gap.
+	dims := tmpl.Layout(gtx, groups)
+	if want := 7*24 + 6; dims.Size.Y != want {
+		t.Errorf("height %d, want %d", dims.Size.Y, want)
+	}
+	if want := []string{"h0", "a", "b", "h1", "c", "h1", "h1"}; !reflect.DeepEqual(built, want) {
+		t.Errorf("built %v, want %v", built, want)
+	}
+}
+
+func TestTemplateText(t *testing.T) {
+	tmpl := MustParseTemplate(`vflex { text("Hello, (world)", h6); text(name) }`)
+	kids := tmpl.root.kids
+	if len(kids) != 2 || kids[0].literal != "Hello, (world)" || !kids[0].quoted || kids[1].quoted {
+		t.Fatalf("parsed %+v", kids)
+	}
+}
+
+func TestTemplateList(t *testing.T) {
+	tmpl := MustParseTemplate(`list(key(tmpl)) { each(.) { slot(row) } }`)
+	var built []int
+	var rows []templateCommit
+	for i := 0; i < 1000; i++ {
+		i := i
+		rows = append(rows, templateCommit{Row: func(gtx C) D {
+			built = append(built, i)
+			return D{Size: image.Pt(gtx.Constraints.Max.X, 20)}
+		}})
+	}
+	defer Forget("tmpl")
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Exact(image.Pt(100, 50))}
+	tmpl.Layout(gtx, rows)
+	if !reflect.DeepEqual(built, []int{0, 1, 2}) {
+		t.Errorf("built rows %v, want [0 1 2]", built)
+	}
+}
+
+func TestTemplateFields(t *testing.T) {
+	type inner struct{ Title string }
+	data := map[string]interface{}{
+		"user": &struct {
+			Inner  *inner
+			hidden string
+		}{Inner: &inner{"x"}, hidden: "y"},
+	}
+	v := reflect.ValueOf(data)
+	if f := field(v, []string{"user", "inner", "title"}); !f.IsValid() || f.String() != "x" {
+		t.Errorf("user.inner.title = %v, want x", f)
+	}
+	if f := field(v, []string{"user", "hidden"}); f.IsValid() {
+		t.Errorf("user.hidden = %v, want none", f)
+	}
+	if f := field(v, []string{"user", "missing"}); f.IsValid() {
+		t.Errorf("user.missing = %v, want none", f)
+	}
+	for _, set := range []interface{}{true, 1, "a", []int{1}, &inner{}} {
+		if !truthy(reflect.ValueOf(set)) {
+			t.Errorf("%v is not set", set)
+		}
+	}
+	for _, unset := range []interface{}{nil, false, 0, "", []int{}, (*inner)(nil)} {
+		if truthy(reflect.ValueOf(unset)) {
+			t.Errorf("%v is set", unset)
+		}
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		offset int
+		err    string
+	}{
+		{`vflex { text(name) `, 6, "missing }"},
+		{`vflex { text(name) } }`, 21, "unexpected }"},
+		{`vflex { text(name) { text(a) } }`, 19, "block without"},
+		{`vflex { inset(4) }`, 8, "missing widget"},
+		{`vflex { each(a);inset(4) { text(b) } }`, 16, "takes a block"},
+		{`vflex { text(name, h7) }`, 19, "unknown text size"},
+		{`vflex { img(a..b) }`, 12, "invalid field"},
+		{`vflex { text(a);insets(4) }`, 8, "unknown directive"},
+		{`vflex { text(a) } vflex`, 0, "single container"},
+		{`text(a)`, 0, "single container"},
+	}
+	for _, test := range tests {
+		_, err := ParseTemplate(test.src)
+		serr, ok := err.(*SyntaxError)
+		if !ok || serr.Offset != test.offset || !strings.Contains(serr.Msg, test.err) {
+			t.Errorf("%q: error %v, want %q at %d", test.src, err, test.err, test.offset)
+		}
+	}
+
+	// Theme references are left to layout, where the Theme may define
+	// them.
+	if _, err := ParseTemplate(`vflex;bkground($surface) { text(name) }`); err != nil {
+		t.Errorf("template with an undefined theme color: %v", err)
+	}
+}
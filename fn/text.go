@@ -0,0 +1,343 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"gioui.org/f32"
+	"gioui.org/font/gofont"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"golang.org/x/image/math/fixed"
+)
+
+// Text returns a widget showing s, styled by style. The text
+// directives of style, and those of the styles enclosing the widget,
+// set its typography, for example
+//
+//	fn.Text("font(caption);color($hint);maxlines(2);ellipsis", msg)
+//
+// Text is shaped with the Material theme of the current Theme, whose
+// text size and color are the defaults, or with the Go fonts if the
+// Theme has none. Other directives in style apply as with Widget.
+func Text(style, s string) layout.Widget {
+	p := lookup(style)
+	l := label(s).Layout
+	return func(gtx C) D {
+		return p.Widget(gtx, l)
+	}
+}
+
+// Font is the typed form of font(size,weight,style), with size in sp.
+func Font(size float32, weight text.Weight, style text.Style) Style {
+	return styleOf(textD{textStyle{
+		set:    sizeProp | weightProp | styleProp,
+		size:   length{v: size, u: unit.UnitSp},
+		weight: weight,
+		style:  style,
+	}})
+}
+
+// TextColor is the typed form of color(c).
+func TextColor(c color.RGBA) Style {
+	return styleOf(textD{textStyle{set: colorProp, color: colorOf(c)}})
+}
+
+// TextAlign is the typed form of align(start|center|end).
+func TextAlign(a text.Alignment) Style {
+	return styleOf(textD{textStyle{set: alignProp, align: a}})
+}
+
+// MaxLines is the typed form of maxlines(n).
+func MaxLines(n int) Style {
+	return styleOf(textD{textStyle{set: maxLinesProp, maxLines: n}})
+}
+
+// Ellipsis is the typed form of ellipsis.
+func Ellipsis() Style {
+	return styleOf(textD{textStyle{set: ellipsisProp, ellipsis: true}})
+}
+
+// LineHeight is the typed form of lineheight(x).
+func LineHeight(x float32) Style {
+	return styleOf(textD{textStyle{set: lineHeightProp, lineHeight: x}})
+}
+
+// typography holds the text sizes of font(name), relative to the text
+// size of the theme, following the Material type scale.
+var typography = map[string]float32{
+	"h1":      96.0 / 16.0,
+	"h2":      60.0 / 16.0,
+	"h3":      48.0 / 16.0,
+	"h4":      34.0 / 16.0,
+	"h5":      24.0 / 16.0,
+	"h6":      20.0 / 16.0,
+	"body1":   1,
+	"body2":   14.0 / 16.0,
+	"caption": 12.0 / 16.0,
+}
+
+var weights = map[string]text.Weight{
+	"normal": text.Normal,
+	"medium": text.Medium,
+	"bold":   text.Bold,
+}
+
+var textAligns = map[string]text.Alignment{
+	"start":  text.Start,
+	"center": text.Middle,
+	"end":    text.End,
+}
+
+type textProp uint8
+
+const (
+	sizeProp textProp = 1 << iota
+	weightProp
+	styleProp
+	colorProp
+	alignProp
+	maxLinesProp
+	ellipsisProp
+	lineHeightProp
+)
+
+// textStyle is the typography set by text directives. Only the
+// properties in set apply.
+type textStyle struct {
+	set textProp
+	// size is the text size, or scale times the size of the theme if
+	// scale is set.
+	size       length
+	scale      float32
+	weight     text.Weight
+	style      text.Style
+	color      colorRef
+	align      text.Alignment
+	maxLines   int
+	ellipsis   bool
+	lineHeight float32
+}
+
+// merge returns t with the properties set in s.
+func (t textStyle) merge(s textStyle) textStyle {
+	if s.set&sizeProp != 0 {
+		t.size, t.scale = s.size, s.scale
+	}
+	if s.set&weightProp != 0 {
+		t.weight = s.weight
+	}
+	if s.set&styleProp != 0 {
+		t.style = s.style
+	}
+	if s.set&colorProp != 0 {
+		t.color = s.color
+	}
+	if s.set&alignProp != 0 {
+		t.align = s.align
+	}
+	if s.set&maxLinesProp != 0 {
+		t.maxLines = s.maxLines
+	}
+	if s.set&ellipsisProp != 0 {
+		t.ellipsis = s.ellipsis
+	}
+	if s.set&lineHeightProp != 0 {
+		t.lineHeight = s.lineHeight
+	}
+	t.set |= s.set
+	return t
+}
+
+// textOf returns the typography of the text directives enclosing gtx.
+func textOf(gtx C) textStyle {
+	return queueOf(gtx).text
+}
+
+// textD passes its typography to the text within its widget.
+type textD struct {
+	s textStyle
+}
+
+func (d textD) Layout(gtx C, w layout.Widget) D {
+	q := queueOf(gtx)
+	q.text = q.text.merge(d.s)
+	return withStyle(gtx, q, w)
+}
+
+// fallback is the Material theme of text when the current Theme has
+// none.
+var fallback struct {
+	once sync.Once
+	th   *material.Theme
+}
+
+// textTheme returns the Material theme text is shaped with.
+func textTheme() *material.Theme {
+	if th := CurrentTheme().Material; th != nil {
+		return th
+	}
+	fallback.once.Do(func() {
+		fallback.th = material.NewTheme(gofont.Collection())
+	})
+	return fallback.th
+}
+
+// label lays out text in the typography of the enclosing directives.
+type label string
+
+func (l label) Layout(gtx C) D {
+	th := textTheme()
+	ts := textOf(gtx)
+	font := text.Font{Weight: ts.weight, Style: ts.style}
+	size := th.TextSize
+	switch {
+	case ts.set&sizeProp == 0:
+	case ts.scale > 0:
+		size = size.Scale(ts.scale)
+	default:
+		size = ts.size.value()
+	}
+	col := th.Color.Text
+	if ts.set&colorProp != 0 {
+		col = ts.color.rgba()
+	}
+	lh := ts.lineHeight
+	if lh <= 0 {
+		lh = 1
+	}
+
+	txt := string(l)
+	shaper := th.Shaper
+	cs := gtx.Constraints
+	ppem := fixed.I(gtx.Px(size))
+	lines := shaper.LayoutString(font, ppem, cs.Max.X, txt)
+	// strs are the texts of lines. Most texts are short enough for their
+	// lines to stay on the stack.
+	strs := make([]string, 0, 8)
+	off := 0
+	for _, line := range lines {
+		strs = append(strs, txt[off:off+line.Len])
+		off += line.Len
+	}
+	if max := ts.maxLines; max > 0 && len(lines) > max {
+		lines, strs = lines[:max], strs[:max]
+		if ts.ellipsis {
+			// The shaper caches lines.
+			lines = append([]text.Line(nil), lines...)
+			last := max - 1
+			lines[last], strs[last] = ellipsize(shaper, font, ppem, cs.Max.X, strs[last])
+		}
+	}
+
+	// Lay out the baselines, lh apart, on the pixel grid.
+	baselines := make([]int, 0, 8)
+	var width, y, prevDesc fixed.Int26_6
+	for i, line := range lines {
+		if i == 0 {
+			y = line.Ascent
+		} else {
+			y += fixed.Int26_6(float32(prevDesc+line.Ascent) * lh)
+		}
+		y = fixed.I(y.Ceil())
+		baselines = append(baselines, y.Round())
+		prevDesc = line.Descent
+		if line.Width > width {
+			width = line.Width
+		}
+	}
+	var dims D
+	if len(lines) > 0 {
+		h := (y + prevDesc).Ceil()
+		dims = D{Size: image.Pt(width.Ceil(), h), Baseline: h - baselines[0]}
+	}
+	dims.Size = cs.Constrain(dims.Size)
+
+	paint.ColorOp{Color: Fade(gtx, col)}.Add(gtx.Ops)
+	for i, line := range lines {
+		var x int
+		switch ts.align {
+		case text.Middle:
+			x = (fixed.I(dims.Size.X) - line.Width).Floor() / 2
+		case text.End:
+			x = (fixed.I(dims.Size.X) - line.Width).Floor()
+		}
+		stack := op.Push(gtx.Ops)
+		op.Offset(f32.Point{X: float32(x), Y: float32(baselines[i])}).Add(gtx.Ops)
+		shaper.ShapeString(font, ppem, strs[i], line.Layout).Add(gtx.Ops)
+		b := line.Bounds
+		paint.PaintOp{Rect: f32.Rectangle{
+			Min: f32.Point{X: float32(b.Min.X.Floor()), Y: float32(b.Min.Y.Floor())},
+			Max: f32.Point{X: float32(b.Max.X.Ceil()), Y: float32(b.Max.Y.Ceil())},
+		}}.Add(gtx.Ops)
+		stack.Pop()
+	}
+	return dims
+}
+
+// ellipsize shortens the line s until it fits maxWidth with an
+// ellipsis appended.
+func ellipsize(shaper text.Shaper, font text.Font, ppem fixed.Int26_6, maxWidth int, s string) (text.Line, string) {
+	s = strings.TrimRightFunc(s, unicode.IsSpace)
+	for {
+		str := s + "…"
+		lines := shaper.LayoutString(font, ppem, maxWidth, str)
+		if len(lines) == 1 || s == "" {
+			return lines[0], str
+		}
+		_, n := utf8.DecodeLastRuneInString(s)
+		s = strings.TrimRightFunc(s[:len(s)-n], unicode.IsSpace)
+	}
+}
+
+// font compiles font(size,weight,style), where size is a length in sp
+// by default, or a typography such as h6, body1 or caption.
+func (c *compiler) font(s section) directive {
+	if !c.arity(s, 1, 2, 3) {
+		return nil
+	}
+	d := textD{textStyle{set: sizeProp}}
+	if scale, ok := typography[s.params[0].s]; ok {
+		d.s.scale = scale
+	} else {
+		if p := s.params[0].s; !s.params[0].call && strings.IndexFunc(p, unicode.IsLetter) < 0 {
+			// Bare numbers are in sp.
+			s.params[0].s += "sp"
+		}
+		d.s.size = c.length(s, 0)
+	}
+	if len(s.params) > 1 {
+		p := s.params[1]
+		w, ok := weights[p.s]
+		if n, err := strconv.Atoi(p.s); err == nil && n > 0 {
+			w, ok = text.Weight(n), true
+		}
+		if !ok {
+			c.errorf(p.off, s.name, "invalid weight %q", p.s)
+		}
+		d.s.set |= weightProp
+		d.s.weight = w
+	}
+	if len(s.params) > 2 {
+		switch p := s.params[2]; p.s {
+		case "regular":
+		case "italic":
+			d.s.style = text.Italic
+		default:
+			c.errorf(p.off, s.name, "invalid style %q", p.s)
+		}
+		d.s.set |= styleProp
+	}
+	return d
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/font/gofont"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget/material"
+)
+
+func TestText(t *testing.T) {
+	defer SetTheme(nil)
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(100, 600)}}
+	size := func(w layout.Widget) image.Point {
+		ops.Reset()
+		return w(gtx).Size
+	}
+
+	fallback := size(Text("", "Hello"))
+	SetTheme(MaterialTheme(material.NewTheme(gofont.Collection())))
+
+	line := size(Text("", "Hello"))
+	if line.X == 0 || line.Y == 0 {
+		t.Fatalf("empty text size %v", line)
+	}
+	if fallback != line {
+		t.Errorf("size without a Material theme %v, want %v", fallback, line)
+	}
+	if h1, caption := size(Text("font(h1)", "Hello")), size(Text("font(caption)", "Hello")); h1.Y <= line.Y || caption.Y >= line.Y {
+		t.Errorf("heights h1 %d, body1 %d, caption %d", h1.Y, line.Y, caption.Y)
+	}
+	if sz := size(Text("font(16,bold,italic);color(ff0000);align(center);inset(4)", "Hello")); sz.Y != line.Y+8 {
+		t.Errorf("styled height %d, want %d", sz.Y, line.Y+8)
+	}
+
+	long := "The quick brown fox jumps over the lazy dog"
+	wrapped := size(Text("", long))
+	if wrapped.Y <= line.Y || wrapped.X > 100 {
+		t.Fatalf("wrapped size %v", wrapped)
+	}
+	if sz := size(Text("maxlines(1);ellipsis", long)); sz.Y != line.Y || sz.X > 100 {
+		t.Errorf("ellipsized size %v, want height %d within 100", sz, line.Y)
+	}
+	if sz := size(Text("lineheight(2)", long)); sz.Y <= wrapped.Y {
+		t.Errorf("lineheight(2) height %d, want more than %d", sz.Y, wrapped.Y)
+	}
+
+	// Typography is inherited.
+	inherited := size(FormatF("vflex;font(h1)", Child("", Text("", "Hello"))))
+	if h1 := size(Text("font(h1)", "Hello")); inherited != h1 {
+		t.Errorf("inherited size %v, want %v", inherited, h1)
+	}
+	// A disabled subtree stays disabled, and still inherits typography.
+	gtx = gtx.Disabled()
+	disabled := size(FormatF("vflex;font(h1)", Child("", func(gtx C) D {
+		if gtx.Queue != nil {
+			t.Error("font enabled a disabled widget")
+		}
+		return Text("", "Hello")(gtx)
+	})))
+	if disabled != inherited {
+		t.Errorf("disabled size %v, want %v", disabled, inherited)
+	}
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image/color"
+	"sync/atomic"
+
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// Theme holds the named values style strings refer to with a $ prefix,
+// as in bkground($surface) or inset($gap). References are resolved
+// every time a style is laid out, so switching the theme restyles every
+// Format and Widget call on the next frame.
+//
+// A Theme must not be modified after it is passed to SetTheme; build a
+// new one and set that instead.
+type Theme struct {
+	// Colors are referenced from color parameters.
+	Colors map[string]color.RGBA
+	// Spacing and Radii are referenced from numeric parameters, in dp.
+	// A name defined in both resolves to its Spacing value.
+	Spacing map[string]float32
+	Radii   map[string]float32
+	// Material is the theme the palette was seeded from, if any.
+	Material *material.Theme
+}
+
+// NewTheme returns an empty Theme.
+func NewTheme() *Theme {
+	return &Theme{
+		Colors:  make(map[string]color.RGBA),
+		Spacing: make(map[string]float32),
+		Radii:   make(map[string]float32),
+	}
+}
+
+// MaterialTheme returns a Theme whose palette is seeded from th, with
+// the colors primary, text, hint and invtext.
+func MaterialTheme(th *material.Theme) *Theme {
+	t := NewTheme()
+	t.Material = th
+	t.Colors["primary"] = th.Color.Primary
+	t.Colors["text"] = th.Color.Text
+	t.Colors["hint"] = th.Color.Hint
+	t.Colors["invtext"] = th.Color.InvText
+	return t
+}
+
+var theme atomic.Value
+
+func init() {
+	theme.Store(NewTheme())
+}
+
+// SetTheme sets the process wide theme.
+func SetTheme(t *Theme) {
+	if t == nil {
+		t = NewTheme()
+	}
+	theme.Store(t)
+}
+
+// CurrentTheme returns the process wide theme.
+func CurrentTheme() *Theme {
+	return theme.Load().(*Theme)
+}
+
+// color returns the named color, or transparent if it is undefined.
+func (t *Theme) color(name string) color.RGBA {
+	return t.Colors[name]
+}
+
+// length returns the named spacing or radius, or 0 if it is undefined.
+func (t *Theme) length(name string) float32 {
+	if v, ok := t.Spacing[name]; ok {
+		return v
+	}
+	return t.Radii[name]
+}
+
+// defines reports whether t defines the named color, or the named
+// spacing or radius.
+func (t *Theme) defines(name string, color bool) bool {
+	if color {
+		_, ok := t.Colors[name]
+		return ok
+	}
+	_, ok1 := t.Spacing[name]
+	_, ok2 := t.Radii[name]
+	return ok1 || ok2
+}
+
+// length is a numeric parameter with a unit, or a reference to a theme
+// spacing or radius in dp.
+type length struct {
+	v   float32
+	u   unit.Unit
+	ref string
+}
+
+func dp(v float32) length {
+	return length{v: v, u: unit.UnitDp}
+}
+
+func (l length) value() unit.Value {
+	if l.ref == "" {
+		return unit.Value{V: l.v, U: l.u}
+	}
+	return unit.Dp(CurrentTheme().length(l.ref))
+}
+
+func (l length) px(gtx C) int {
+	return gtx.Px(l.value())
+}
+
+// colorRef is a color parameter, or a reference to a theme color.
+type colorRef struct {
+	c   color.RGBA
+	ref string
+}
+
+func colorOf(c color.RGBA) colorRef {
+	return colorRef{c: c}
+}
+
+func (p colorRef) rgba() color.RGBA {
+	if p.ref == "" {
+		return p.c
+	}
+	return CurrentTheme().color(p.ref)
+}
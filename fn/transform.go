@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image/color"
+	"math"
+	"sync"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// Opacity is the typed form of opacity(a).
+func Opacity(a float32) Style {
+	return styleOf(opacityS{a})
+}
+
+// Offset is the typed form of offset(x,y).
+func Offset(x, y float32) Style {
+	return styleOf(offsetS{dp(x), dp(y)})
+}
+
+// Scale is the typed form of scale(s).
+func Scale(s float32) Style {
+	return styleOf(scaleS{s, s})
+}
+
+// ScaleXY is the typed form of scale(sx,sy).
+func ScaleXY(sx, sy float32) Style {
+	return styleOf(scaleS{sx, sy})
+}
+
+// Rotate is the typed form of rotate(deg).
+func Rotate(deg float32) Style {
+	return styleOf(rotateS{deg, layout.Center})
+}
+
+// RotateAround is the typed form of rotate(deg,origin).
+func RotateAround(deg float32, origin layout.Direction) Style {
+	return styleOf(rotateS{deg, origin})
+}
+
+// Fade returns c faded by the opacity directives enclosing gtx. Gio has
+// no layer opacity, so opacity(a) only fades what fn paints itself;
+// widgets that paint their own colors can use Fade to follow it.
+func Fade(gtx C, c color.RGBA) color.RGBA {
+	a := opacity(gtx)
+	if a == 1 {
+		return c
+	}
+	return color.RGBA{
+		R: uint8(float32(c.R)*a + .5),
+		G: uint8(float32(c.G)*a + .5),
+		B: uint8(float32(c.B)*a + .5),
+		A: uint8(float32(c.A)*a + .5),
+	}
+}
+
+// opacity returns the opacity of the directives enclosing gtx.
+func opacity(gtx C) float32 {
+	q := queueOf(gtx)
+	switch {
+	case q.alpha >= 1:
+		return 1
+	case q.alpha < 0:
+		return 0
+	}
+	return q.alpha
+}
+
+// styleQueue carries the opacity of the enclosing opacity directives,
+// the interaction states of the nearest interactive widget, the
+// typography of the enclosing text directives and whether the widget is
+// within a box of the layout inspector, since Context has no other
+// place for them.
+type styleQueue struct {
+	event.Queue
+	alpha  float32
+	states State
+	text   textStyle
+	boxed  bool
+}
+
+// styleStacks holds the styleQueues of the layouts in progress on each
+// op.Ops, innermost last. A nil Queue means a disabled widget, so it
+// cannot be wrapped, and widgets laid out within may also disable their
+// own children; queueOf falls back to styleStacks for them. The stacks
+// and their styleQueues are kept for the next frame, so that layouts do
+// not allocate.
+var styleStacks = struct {
+	sync.Mutex
+	m map[*op.Ops]*styleStack
+}{m: make(map[*op.Ops]*styleStack)}
+
+type styleStack struct {
+	qs []*styleQueue
+	// n is the number of styleQueues in use.
+	n int
+}
+
+// queueOf returns the styleQueue enclosing gtx, whose Queue is that of
+// gtx.
+func queueOf(gtx C) styleQueue {
+	if q, ok := gtx.Queue.(*styleQueue); ok {
+		return *q
+	}
+	q := styleQueue{alpha: 1}
+	styleStacks.Lock()
+	if s := styleStacks.m[gtx.Ops]; s != nil && s.n > 0 {
+		q = *s.qs[s.n-1]
+	}
+	styleStacks.Unlock()
+	q.Queue = gtx.Queue
+	return q
+}
+
+// withStyle lays out w within the styleQueue q, which must be derived
+// from queueOf(gtx).
+func withStyle(gtx C, q styleQueue, w layout.Widget) D {
+	styleStacks.Lock()
+	s := styleStacks.m[gtx.Ops]
+	if s == nil {
+		s = new(styleStack)
+		styleStacks.m[gtx.Ops] = s
+	}
+	if s.n == len(s.qs) {
+		s.qs = append(s.qs, new(styleQueue))
+	}
+	p := s.qs[s.n]
+	*p = q
+	s.n++
+	styleStacks.Unlock()
+	defer func() {
+		styleStacks.Lock()
+		s.n--
+		*p = styleQueue{}
+		styleStacks.Unlock()
+	}()
+	if gtx.Queue != nil {
+		gtx.Queue = p
+	}
+	return w(gtx)
+}
+
+type opacityS struct {
+	a float32
+}
+
+func (s opacityS) Layout(gtx C, w layout.Widget) D {
+	if s.a >= 1 {
+		return w(gtx)
+	}
+	q := queueOf(gtx)
+	q.alpha *= s.a
+	return withStyle(gtx, q, w)
+}
+
+// offsetS moves a widget without changing its dimensions.
+type offsetS struct {
+	x, y length
+}
+
+func (s offsetS) Layout(gtx C, w layout.Widget) D {
+	defer op.Push(gtx.Ops).Pop()
+	op.Offset(f32.Point{
+		X: float32(s.x.px(gtx)),
+		Y: float32(s.y.px(gtx)),
+	}).Add(gtx.Ops)
+	return w(gtx)
+}
+
+// scaleS scales a widget around its center without changing its
+// dimensions.
+type scaleS struct {
+	sx, sy float32
+}
+
+func (s scaleS) Layout(gtx C, w layout.Widget) D {
+	return transform(gtx, w, func(sz f32.Point) f32.Affine2D {
+		return f32.Affine2D{}.Scale(sz.Mul(.5), f32.Point{X: s.sx, Y: s.sy})
+	})
+}
+
+// rotateS rotates a widget clockwise around a point of its bounds
+// without changing its dimensions.
+type rotateS struct {
+	deg    float32
+	origin layout.Direction
+}
+
+func (s rotateS) Layout(gtx C, w layout.Widget) D {
+	return transform(gtx, w, func(sz f32.Point) f32.Affine2D {
+		return f32.Affine2D{}.Rotate(originOf(s.origin, sz), s.deg*math.Pi/180)
+	})
+}
+
+// transform lays out w and applies the transformation returned by t for
+// its size. The transformation applies to pointer input areas as well,
+// so hit testing follows the transformed widget.
+func transform(gtx C, w layout.Widget, t func(sz f32.Point) f32.Affine2D) D {
+	m := op.Record(gtx.Ops)
+	dims := w(gtx)
+	call := m.Stop()
+
+	defer op.Push(gtx.Ops).Pop()
+	op.Affine(t(layout.FPt(dims.Size))).Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	return dims
+}
+
+// originOf returns the point of a rectangle of size sz that d aligns to.
+func originOf(d layout.Direction, sz f32.Point) f32.Point {
+	var p f32.Point
+	switch d {
+	case layout.N, layout.Center, layout.S:
+		p.X = sz.X / 2
+	case layout.NE, layout.E, layout.SE:
+		p.X = sz.X
+	}
+	switch d {
+	case layout.W, layout.Center, layout.E:
+		p.Y = sz.Y / 2
+	case layout.SW, layout.S, layout.SE:
+		p.Y = sz.Y
+	}
+	return p
+}
@@ -0,0 +1,417 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+// clock is the time source of transitions.
+var clock struct {
+	sync.RWMutex
+	now func() time.Time
+}
+
+// SetClock sets the time source of transitions, so that tests can step
+// animations deterministically. The default, restored by nil, is the
+// frame time of the Context, or time.Now if the Context has none.
+func SetClock(now func() time.Time) {
+	clock.Lock()
+	clock.now = now
+	clock.Unlock()
+}
+
+// frameTime returns the time transitions see in gtx.
+func frameTime(gtx C) time.Time {
+	clock.RLock()
+	now := clock.now
+	clock.RUnlock()
+	switch {
+	case now != nil:
+		return now()
+	case !gtx.Now.IsZero():
+		return gtx.Now
+	}
+	return time.Now()
+}
+
+// easing maps the elapsed fraction of a transition to the fraction of
+// the change applied.
+type easing func(t float32) float32
+
+var easings = map[string]easing{
+	"linear":    func(t float32) float32 { return t },
+	"ease":      cubicBezier(.25, .1, .25, 1),
+	"easein":    cubicBezier(.42, 0, 1, 1),
+	"easeout":   cubicBezier(0, 0, .58, 1),
+	"easeinout": cubicBezier(.42, 0, .58, 1),
+}
+
+// cubicBezier returns the easing of the CSS cubic-bezier(x1,y1,x2,y2)
+// timing function.
+func cubicBezier(x1, y1, x2, y2 float32) easing {
+	bezier := func(p1, p2, t float32) float32 {
+		u := 1 - t
+		return 3*u*u*t*p1 + 3*u*t*t*p2 + t*t*t
+	}
+	return func(x float32) float32 {
+		if x <= 0 || x >= 1 {
+			return x
+		}
+		// Find t for x by bisection, since x grows with t.
+		lo, hi := float32(0), float32(1)
+		for i := 0; i < 24; i++ {
+			t := (lo + hi) / 2
+			if bezier(x1, x2, t) < x {
+				lo = t
+			} else {
+				hi = t
+			}
+		}
+		return bezier(y1, y2, (lo+hi)/2)
+	}
+}
+
+// vec holds the animated values of a directive.
+type vec [4]float32
+
+// tweener is implemented by the directives transitions animate.
+type tweener interface {
+	directive
+	// prop is the name of the animated property.
+	prop() string
+	// values returns the values of the directive in gtx.
+	values(gtx C) vec
+	// rest returns the values at which the directive has no effect, for
+	// a widget of natural size sz, or zero if unknown.
+	rest(gtx C, sz image.Point) vec
+	// tween returns the directive with values v. It reuses prev if it is
+	// a directive returned by an earlier call, so that animating does not
+	// allocate every frame.
+	tween(prev directive, v vec) directive
+}
+
+// transitionD records the transition of a property. The directives it
+// animates are bound when the style is compiled, so it lays out its
+// widget as is.
+type transitionD struct {
+	prop string
+	dur  time.Duration
+	ease easing
+}
+
+func (d transitionD) Layout(gtx C, w layout.Widget) D {
+	return w(gtx)
+}
+
+// animator is the state of the transitions of a widget, with a slot per
+// animated directive.
+type animator struct {
+	slots []slot
+}
+
+type slot struct {
+	set      bool
+	from, to vec
+	start    time.Time
+	// natural is the size of the widget while the directive is off.
+	natural image.Point
+	// tweened is the directive last laid out in the slot.
+	tweened directive
+}
+
+func animatorFor(key interface{}) *animator {
+	return stateFor(animState, key, func() interface{} { return new(animator) }).(*animator)
+}
+
+func (a *animator) slot(i int) *slot {
+	for len(a.slots) <= i {
+		a.slots = append(a.slots, slot{})
+	}
+	return &a.slots[i]
+}
+
+// animate moves the slot towards target and returns its values at now,
+// and whether they have settled.
+func (s *slot) animate(now time.Time, target vec, dur time.Duration, ease easing) (vec, bool) {
+	if !s.set {
+		s.set, s.from, s.to, s.start = true, target, target, now
+		return target, true
+	}
+	if target != s.to {
+		cur, _ := s.at(now, dur, ease)
+		s.from, s.to, s.start = cur, target, now
+	}
+	return s.at(now, dur, ease)
+}
+
+func (s *slot) at(now time.Time, dur time.Duration, ease easing) (vec, bool) {
+	el := now.Sub(s.start)
+	if dur <= 0 || el >= dur {
+		return s.to, true
+	}
+	if el < 0 {
+		el = 0
+	}
+	t := ease(float32(el) / float32(dur))
+	var v vec
+	for i := range v {
+		v[i] = s.from[i] + (s.to[i]-s.from[i])*t
+	}
+	return v, false
+}
+
+// tweenD animates a directive, in the given states if any.
+type tweenD struct {
+	d      tweener
+	states State
+	slot   int
+	dur    time.Duration
+	ease   easing
+	key    interface{}
+}
+
+func (t tweenD) Layout(gtx C, w layout.Widget) D {
+	on := t.states == 0 || statesOf(gtx)&t.states != 0
+	s := animatorFor(t.key).slot(t.slot)
+	target := t.d.rest(gtx, s.natural)
+	if on {
+		target = t.d.values(gtx)
+	}
+	v, settled := s.animate(frameTime(gtx), target, t.dur, t.ease)
+	if !settled {
+		op.InvalidateOp{}.Add(gtx.Ops)
+	}
+	if settled && !on {
+		dims := w(gtx)
+		if dims.Size != s.natural {
+			// Follow the natural size without animating it.
+			s.natural = dims.Size
+			s.from = t.d.rest(gtx, dims.Size)
+			s.to = s.from
+		}
+		return dims
+	}
+	s.tweened = t.d.tween(s.tweened, v)
+	return s.tweened.Layout(gtx, w)
+}
+
+// transitions binds the animated directives of chain to the transitions
+// of their properties, keeping the animations under the chain's
+// key(name). It reports false if the chain has transitions but no key.
+func transitions(chain []directive) ([]directive, bool) {
+	specs := make(map[string]transitionD)
+	var key interface{}
+	for _, d := range chain {
+		switch d := d.(type) {
+		case transitionD:
+			specs[d.prop] = d
+		case interactS:
+			if key == nil {
+				key = d.key
+			}
+		}
+	}
+	if len(specs) == 0 {
+		return chain, true
+	}
+	if key == nil {
+		return chain, false
+	}
+	n := 0
+	out := chain[:0]
+	for _, d := range chain {
+		var states State
+		if s, ok := d.(stateD); ok {
+			states, d = s.states, s.d
+		}
+		tw, ok := d.(tweener)
+		if !ok {
+			if _, ok := d.(transitionD); !ok {
+				out = append(out, chainD(d, states))
+			}
+			continue
+		}
+		spec, ok := specs[tw.prop()]
+		if !ok {
+			spec, ok = specs["all"]
+		}
+		if !ok {
+			out = append(out, chainD(d, states))
+			continue
+		}
+		out = append(out, tweenD{d: tw, states: states, slot: n, dur: spec.dur, ease: spec.ease, key: key})
+		n++
+	}
+	return out, true
+}
+
+// chainD rewraps a directive taken out of a state section.
+func chainD(d directive, states State) directive {
+	if states == 0 {
+		return d
+	}
+	return stateD{states, d}
+}
+
+// transition compiles transition(prop,duration[,easing]).
+func (c *compiler) transition(s section) directive {
+	if !c.arity(s, 2, 3) {
+		return nil
+	}
+	if c.transSec == nil {
+		c.transSec = &s
+	}
+	d := transitionD{prop: s.params[0].s, ease: easings["ease"]}
+	switch d.prop {
+	case "all", "bkground", "size", "opacity", "offset", "scale":
+	default:
+		c.errorf(s.params[0].off, s.name, "cannot animate %q", d.prop)
+		return nil
+	}
+	dur, ok := parseDuration(s.params[1].s)
+	if !ok {
+		c.errorf(s.params[1].off, s.name, "invalid duration %q", s.params[1].s)
+		return nil
+	}
+	d.dur = dur
+	if len(s.params) == 3 {
+		e, ok := easings[s.params[2].s]
+		if !ok {
+			c.errorf(s.params[2].off, s.name, "unknown easing %q", s.params[2].s)
+			return nil
+		}
+		d.ease = e
+	}
+	return d
+}
+
+// parseDuration parses a duration such as 200ms or 0.2s, where a bare
+// number is in milliseconds.
+func parseDuration(s string) (time.Duration, bool) {
+	if v, err := strconv.ParseFloat(s, 32); err == nil {
+		return time.Duration(v * float64(time.Millisecond)), v >= 0
+	}
+	if !strings.HasSuffix(s, "s") {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil && d >= 0
+}
+
+func (s backgroundS) prop() string { return "bkground" }
+
+func (s backgroundS) values(gtx C) vec {
+	c := s.col.rgba()
+	return vec{float32(c.R), float32(c.G), float32(c.B), float32(c.A)}
+}
+
+func (s backgroundS) rest(gtx C, sz image.Point) vec { return vec{} }
+
+func (s backgroundS) tween(prev directive, v vec) directive {
+	d, ok := prev.(*backgroundS)
+	if !ok {
+		d = new(backgroundS)
+	}
+	*d = s
+	d.col = colorOf(color.RGBA{R: channel(v[0]), G: channel(v[1]), B: channel(v[2]), A: channel(v[3])})
+	return d
+}
+
+func channel(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + .5)
+}
+
+func (s sizeS) prop() string { return "size" }
+
+func (s sizeS) values(gtx C) vec {
+	var v vec
+	if s.width.value().V > 0 {
+		v[0] = float32(s.width.px(gtx))
+	}
+	if s.height.value().V > 0 {
+		v[1] = float32(s.height.px(gtx))
+	}
+	return v
+}
+
+// rest is the natural size, along the axes the directive sets.
+func (s sizeS) rest(gtx C, sz image.Point) vec {
+	v := s.values(gtx)
+	if sz == (image.Point{}) {
+		return v
+	}
+	if v[0] > 0 {
+		v[0] = float32(sz.X)
+	}
+	if v[1] > 0 {
+		v[1] = float32(sz.Y)
+	}
+	return v
+}
+
+func (s sizeS) tween(prev directive, v vec) directive {
+	d, ok := prev.(*sizeS)
+	if !ok {
+		d = new(sizeS)
+	}
+	*d = sizeS{length{v: v[0], u: unit.UnitPx}, length{v: v[1], u: unit.UnitPx}}
+	return d
+}
+
+func (s opacityS) prop() string                   { return "opacity" }
+func (s opacityS) values(gtx C) vec               { return vec{s.a} }
+func (s opacityS) rest(gtx C, sz image.Point) vec { return vec{1} }
+
+func (s opacityS) tween(prev directive, v vec) directive {
+	d, ok := prev.(*opacityS)
+	if !ok {
+		d = new(opacityS)
+	}
+	*d = opacityS{v[0]}
+	return d
+}
+
+func (s scaleS) prop() string                   { return "scale" }
+func (s scaleS) values(gtx C) vec               { return vec{s.sx, s.sy} }
+func (s scaleS) rest(gtx C, sz image.Point) vec { return vec{1, 1} }
+
+func (s scaleS) tween(prev directive, v vec) directive {
+	d, ok := prev.(*scaleS)
+	if !ok {
+		d = new(scaleS)
+	}
+	*d = scaleS{v[0], v[1]}
+	return d
+}
+
+func (s offsetS) prop() string                   { return "offset" }
+func (s offsetS) rest(gtx C, sz image.Point) vec { return vec{} }
+
+func (s offsetS) values(gtx C) vec {
+	return vec{float32(s.x.px(gtx)), float32(s.y.px(gtx))}
+}
+
+func (s offsetS) tween(prev directive, v vec) directive {
+	d, ok := prev.(*offsetS)
+	if !ok {
+		d = new(offsetS)
+	}
+	*d = offsetS{length{v: v[0], u: unit.UnitPx}, length{v: v[1], u: unit.UnitPx}}
+	return d
+}
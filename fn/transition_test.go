@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gioui.org/io/router"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestEasings(t *testing.T) {
+	for name, e := range easings {
+		if e(0) != 0 || e(1) != 1 {
+			t.Errorf("%s: ends at %v and %v, want 0 and 1", name, e(0), e(1))
+		}
+		prev := float32(0)
+		for i := 1; i <= 10; i++ {
+			v := e(float32(i) / 10)
+			if v < prev {
+				t.Errorf("%s: decreases at %v", name, float32(i)/10)
+			}
+			prev = v
+		}
+	}
+	if v := easings["easein"](.5); v >= .5 {
+		t.Errorf("easein(0.5) = %v, want less than 0.5", v)
+	}
+	if v := easings["easeout"](.5); v <= .5 {
+		t.Errorf("easeout(0.5) = %v, want more than 0.5", v)
+	}
+}
+
+func TestTransition(t *testing.T) {
+	now := time.Unix(0, 0)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+	defer Forget("tr")
+
+	var ops op.Ops
+	var r router.Router
+	frame := func(size int) image.Point {
+		ops.Reset()
+		gtx := layout.Context{Ops: &ops, Queue: &r, Constraints: layout.Constraints{Max: image.Pt(400, 400)}}
+		style := "key(tr);transition(size,100ms,linear);size(50,50)"
+		if size == 100 {
+			style = "key(tr);transition(size,100ms,linear);size(100,100)"
+		}
+		dims := Widget(gtx, style, box)
+		r.Frame(&ops)
+		return dims.Size
+	}
+	tests := []struct {
+		step   time.Duration
+		size   int
+		want   int
+		wakeup bool
+	}{
+		// The first layout does not animate.
+		{0, 50, 50, false},
+		{0, 100, 50, true},
+		{50 * time.Millisecond, 100, 75, true},
+		{50 * time.Millisecond, 100, 100, false},
+		// A change during a transition starts from where it is.
+		{0, 50, 100, true},
+		{50 * time.Millisecond, 50, 75, true},
+		{0, 100, 75, true},
+		{100 * time.Millisecond, 100, 100, false},
+	}
+	for i, test := range tests {
+		now = now.Add(test.step)
+		if sz := frame(test.size); sz != image.Pt(test.want, test.want) {
+			t.Errorf("%d: size %v, want %d", i, sz, test.want)
+		}
+		if _, wakeup := r.WakeupTime(); wakeup != test.wakeup {
+			t.Errorf("%d: wakeup %v, want %v", i, wakeup, test.wakeup)
+		}
+	}
+}
+
+func TestStateTransition(t *testing.T) {
+	now := time.Unix(0, 0)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+	defer Forget("trs")
+
+	var ops op.Ops
+	var alpha float32
+	w := func(gtx C) D {
+		alpha = opacity(gtx)
+		return box(gtx)
+	}
+	gtx := layout.Context{Ops: &ops, Constraints: layout.Constraints{Max: image.Pt(400, 400)}}
+	style := "key(trs);transition(opacity,100ms,linear);:disabled opacity(0.5)"
+	steps := []struct {
+		step     time.Duration
+		disabled bool
+		want     float32
+	}{
+		{0, false, 1},
+		{0, true, 1},
+		{50 * time.Millisecond, true, .75},
+		{50 * time.Millisecond, true, .5},
+		{0, false, .5},
+		{100 * time.Millisecond, false, 1},
+	}
+	for i, s := range steps {
+		now = now.Add(s.step)
+		SetDisabled("trs", s.disabled)
+		ops.Reset()
+		Widget(gtx, style, w)
+		if alpha != s.want {
+			t.Errorf("%d: opacity %v, want %v", i, alpha, s.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fn
+
+import (
+	"image"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// Wrap is the typed form of wrap(alignment,spacing,gap(h,v)).
+type Wrap struct {
+	// Alignment aligns the children of a line vertically.
+	Alignment layout.Alignment
+	// Spacing distributes the space left in each line.
+	Spacing layout.Spacing
+	// Gap is the space between children of a line and LineGap the
+	// space between lines, in dp.
+	Gap, LineGap float32
+}
+
+func (w Wrap) Layout(gtx C, items ...Item) D {
+	return wrapC{w.Alignment, w.Spacing, dp(w.Gap), dp(w.LineGap)}.Layout(gtx, items)
+}
+
+// wrapC lays out children left to right, breaking lines where the next
+// child would exceed the maximum width.
+type wrapC struct {
+	align        layout.Alignment
+	spacing      layout.Spacing
+	gap, lineGap length
+}
+
+func (c wrapC) Layout(gtx C, items []Item) D {
+	var buf [16]flexChild
+	children := buf[:0]
+	if len(items) > len(buf) {
+		children = make([]flexChild, 0, len(items))
+	}
+	children = children[:len(items)]
+
+	cs := gtx.Constraints
+	gap, lineGap := c.gap.px(gtx), c.lineGap.px(gtx)
+	for i, it := range items {
+		m := op.Record(gtx.Ops)
+		gtx := gtx
+		gtx.Constraints.Min = image.Point{}
+		dims := it.Widget(gtx)
+		children[i] = flexChild{m.Stop(), dims}
+	}
+
+	var width, y, baseline int
+	for start := 0; start < len(children); {
+		// Find the children of the line.
+		end, lineWidth := start+1, children[start].dims.Size.X
+		for ; end < len(children); end++ {
+			w := lineWidth + gap + children[end].dims.Size.X
+			if w > cs.Max.X {
+				break
+			}
+			lineWidth = w
+		}
+		line := children[start:end]
+		var height, ascent int
+		for _, ch := range line {
+			if a := ch.dims.Size.Y - ch.dims.Baseline; a > ascent {
+				ascent = a
+			}
+		}
+		for _, ch := range line {
+			h := ch.dims.Size.Y
+			if c.align == layout.Baseline {
+				h += ascent - (ch.dims.Size.Y - ch.dims.Baseline)
+			}
+			if h > height {
+				height = h
+			}
+		}
+		if start == 0 {
+			baseline = ascent
+		}
+
+		n := len(line)
+		var space int
+		if c.spacing != layout.SpaceEnd && cs.Max.X > lineWidth {
+			space = cs.Max.X - lineWidth
+		}
+		x := 0
+		switch c.spacing {
+		case layout.SpaceSides:
+			x = space / 2
+		case layout.SpaceStart:
+			x = space
+		case layout.SpaceEvenly:
+			x = space / (1 + n)
+		case layout.SpaceAround:
+			x = space / (n * 2)
+		}
+		for i, ch := range line {
+			dims := ch.dims
+			var cross int
+			switch c.align {
+			case layout.End:
+				cross = height - dims.Size.Y
+			case layout.Middle:
+				cross = (height - dims.Size.Y) / 2
+			case layout.Baseline:
+				cross = ascent - (dims.Size.Y - dims.Baseline)
+			}
+			stack := op.Push(gtx.Ops)
+			op.Offset(layout.FPt(image.Pt(x, y+cross))).Add(gtx.Ops)
+			ch.call.Add(gtx.Ops)
+			stack.Pop()
+			x += dims.Size.X
+			if i < n-1 {
+				x += gap
+				switch c.spacing {
+				case layout.SpaceEvenly:
+					x += space / (1 + n)
+				case layout.SpaceAround:
+					x += space / n
+				case layout.SpaceBetween:
+					x += space / (n - 1)
+				}
+			}
+		}
+		if space > 0 {
+			lineWidth = cs.Max.X
+		}
+		if lineWidth > width {
+			width = lineWidth
+		}
+		y += height
+		if end < len(children) {
+			y += lineGap
+		}
+		start = end
+	}
+	dims := D{Size: cs.Constrain(image.Pt(width, y))}
+	if c.align == layout.Baseline {
+		dims.Baseline = dims.Size.Y - baseline
+	}
+	return dims
+}
+
+// wrap compiles the parameters of wrap.
+func (c *compiler) wrap(s section) Container {
+	a, sp, gaps := c.flow(s, 2)
+	return wrapC{a, sp, gaps[0], gaps[1]}
+}
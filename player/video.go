@@ -7,6 +7,7 @@ import (
 	"log"
 	"time"
 
+	"gioui.org/f32"
 	"gioui.org/font/gofont"
 	"gioui.org/layout"
 	"gioui.org/op/paint"
@@ -214,19 +215,18 @@ func (p *VideoPlayer) Layout(gtx C) D {
 	var children []fn.ChildSpec
 
 	if p.picture.img != nil {
-		children = append(children, fn.Child("f;dir(center)", func(gtx C) D {
-			mw, mh := gtx.Constraints.Max.X, gtx.Constraints.Max.Y
-			op := paint.NewImageOp(p.picture.img)
-			sz := op.Size()
-			scale := float32(mw) / float32(gtx.Px(unit.Dp(1))) / float32(sz.X)
-			s := float32(mh) / float32(sz.Y)
-			if scale > s {
-				scale = s
-			}
-			return widget.Image{Src: op, Scale: scale}.Layout(gtx)
-		}))
+		img := paint.NewImageOp(p.picture.img)
+		sz := img.Size()
+		// Fit the picture to the space above the controls.
+		children = append(children, fn.Child("f;dir(center)", fn.Styled(func(gtx C) D {
+			size := gtx.Constraints.Min
+			img.Add(gtx.Ops)
+			paint.PaintOp{Rect: f32.Rectangle{Max: layout.FPt(size)}}.Add(gtx.Ops)
+			return D{Size: size}
+		}, fn.Aspect(float32(sz.X)/float32(sz.Y)))))
 	}
-	children = append(children, fn.Child(";border(0,0,0,1,c0c0c0);size(0,50)", func(gtx C) D {
+	// The controls bar fades from the picture into a light gray.
+	children = append(children, fn.Child(";border(0,0,0,1px,c0c0c0);lgradient(90,#fafafa,#e0e0e0,30%,100%);size(0,50)", func(gtx C) D {
 		return p.layoutControls(gtx)
 	}))
 